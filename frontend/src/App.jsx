@@ -3,10 +3,14 @@ import React, { useState, useEffect } from 'react';
 const API_BASE = "http://localhost:8080";
 const USER_ID = "120338e1-bd44-4ee3-9258-01f57c2c5a5c"; // Для примера используем фиксированный ID
 
+// Суммы приходят объектом { amount: "123.45", currency: "RUB" }; amount — строка, без округления float
+const formatMoney = (money) => money ? `${money.amount} ${money.currency}` : '—';
+
 function App() {
-  const [balance, setBalance] = useState(0);
+  const [balance, setBalance] = useState(null);
   const [billId, setBillId] = useState(null);
   const [orders, setOrders] = useState([]);
+  const [products, setProducts] = useState([]);
   const [loading, setLoading] = useState(false);
 
   // 1. Инициализация: получаем или создаем счет
@@ -15,15 +19,22 @@ function App() {
       .then(res => res.json())
       .then(data => {
         setBillId(data.bill_id);
-        setBalance(data.balance);
       });
     refreshOrders();
+    fetch(`${API_BASE}/orders/products`)
+      .then(res => res.json())
+      .then(data => setProducts(Array.isArray(data) ? data : []));
   }, []);
 
+  useEffect(() => {
+    if (billId) refreshBalance();
+  }, [billId]);
+
   const refreshOrders = () => {
-    fetch(`${API_BASE}/orders/orders/${USER_ID}`)
+    // Первая страница истории (новые заказы сверху)
+    fetch(`${API_BASE}/orders/orders/${USER_ID}?limit=50`)
       .then(res => res.json())
-      .then(data => setOrders(Array.isArray(data) ? data : []));
+      .then(data => setOrders(Array.isArray(data.orders) ? data.orders : []));
   };
 
   // 2. Сценарий: Пополнение баланса (кнопка "+")
@@ -31,42 +42,65 @@ function App() {
     const amount = prompt("Введите сумму пополнения:", "100");
     if (!amount || !billId) return;
 
+    // Ключ идемпотентности: повтор того же запроса не пополнит счет второй раз
     await fetch(`${API_BASE}/payments/add/${billId}?user_id=${USER_ID}`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ amount: parseFloat(amount) })
+      headers: { 'Content-Type': 'application/json', 'Idempotency-Key': crypto.randomUUID() },
+      // Сумма передается строкой: сервис отклонит больше двух знаков после запятой
+      body: JSON.stringify({ amount: amount.trim().replace(',', '.'), currency: 'RUB' })
     });
     
-    // Обновляем баланс
+    refreshBalance();
+  };
+
+  const refreshBalance = async () => {
     const res = await fetch(`${API_BASE}/payments/balance/${billId}?user_id=${USER_ID}`);
     const data = await res.json();
     setBalance(data.balance);
   };
 
   // 3. Сценарий: Создание заказа и автооплата (Transactional Outbox)
-  const createOrder = async () => {
+  // Сумму заказа считает сервис по ценам каталога
+  const createOrder = async (product) => {
     setLoading(true);
     const res = await fetch(`${API_BASE}/orders/create/${USER_ID}`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ amount: 50.0, description: "Тестовый заказ" })
+      headers: { 'Content-Type': 'application/json', 'Idempotency-Key': crypto.randomUUID() },
+      body: JSON.stringify({ items: [{ sku: product.sku, quantity: 1 }], description: product.name })
     });
+    if (!res.ok) {
+      setLoading(false);
+      return;
+    }
     const newOrder = await res.json();
     
     // Согласно схеме, процесс асинхронный
-    // Запускаем опрос статуса (polling)
-    const timer = setInterval(async () => {
-      const statusRes = await fetch(`${API_BASE}/orders/status/${newOrder.id}`);
-      const updatedOrder = await statusRes.json();
-      
-      if (updatedOrder.status !== 'new') {
-        clearInterval(timer);
+    // Ждем результата оплаты в потоке событий статусов (Server-Sent Events)
+    const events = new EventSource(`${API_BASE}/orders/orders/${USER_ID}/events`);
+    events.addEventListener('status', (e) => {
+      const event = JSON.parse(e.data);
+      if (event.order_id !== newOrder.id) return;
+
+      // Ждем конечного результата оплаты
+      if (event.to !== 'new' && event.to !== 'payment_pending') {
+        events.close();
         setLoading(false);
         refreshOrders();
         // Также обновляем баланс, так как деньги могли списаться
-        topUp(); 
+        refreshBalance();
       }
-    }, 2000);
+    });
+  };
+
+  // 4. Сценарий: Отмена заказа; оплаченный заказ ждет возврата денег (refund_pending → refunded)
+  const cancelOrder = async (order) => {
+    const res = await fetch(`${API_BASE}/orders/cancel/${order.id}`, { method: 'POST' });
+    if (!res.ok) {
+      const data = await res.json();
+      alert(data.message || 'Не удалось отменить заказ');
+    }
+    refreshOrders();
+    setTimeout(() => { refreshOrders(); refreshBalance(); }, 2000);
   };
 
   return (
@@ -76,27 +110,47 @@ function App() {
       <div style={{ border: '1px solid #ccc', padding: '15px', borderRadius: '8px', marginBottom: '20px' }}>
         <h3>Мой счет (ID: {billId || 'создается...'})</h3>
         <p style={{ fontSize: '24px' }}>
-          Баланс: <strong>{balance} руб.</strong>
+          Баланс: <strong>{formatMoney(balance)}</strong>
           <button onClick={topUp} style={{ marginLeft: '15px', cursor: 'pointer' }}> ➕ Пополнить</button>
         </p>
       </div>
 
-      <button 
-        onClick={createOrder} 
-        disabled={loading}
-        style={{ padding: '10px 20px', backgroundColor: '#007bff', color: 'white', border: 'none', borderRadius: '4px', cursor: 'pointer' }}
-      >
-        {loading ? 'Обработка оплаты...' : 'Создать заказ (50 руб.)'}
-      </button>
+      <h3>Каталог</h3>
+      {loading && <p>Обработка оплаты...</p>}
+      {products.map(product => (
+        <button
+          key={product.id}
+          onClick={() => createOrder(product)}
+          disabled={loading}
+          style={{ padding: '10px 20px', marginRight: '10px', backgroundColor: '#007bff', color: 'white', border: 'none', borderRadius: '4px', cursor: 'pointer' }}
+        >
+          {product.name} ({formatMoney(product.price)})
+        </button>
+      ))}
 
       <h3>История заказов</h3>
       <ul>
         {orders.map(order => (
           <li key={order.id}>
-            Заказ #{order.id}: {order.amount} руб. — 
+            Заказ #{order.id}: {formatMoney(order.amount)} — 
             <span style={{ fontWeight: 'bold', color: order.status === 'finished' ? 'green' : (order.status === 'canceled' ? 'red' : 'orange') }}>
                {order.status}
             </span>
+            {order.cancel_reason && (
+              <span style={{ marginLeft: '10px', color: '#666' }}>
+                {order.cancel_reason.code === 'insufficient_funds'
+                  ? 'Недостаточно средств — пополните баланс'
+                  : order.cancel_reason.message}
+              </span>
+            )}
+            {(order.status === 'payment_pending' || order.status === 'finished') && (
+              <button onClick={() => cancelOrder(order)} style={{ marginLeft: '10px', cursor: 'pointer' }}>Отменить</button>
+            )}
+            <ul>
+              {(order.items || []).map(item => (
+                <li key={item.sku}>{item.name} × {item.quantity} — {formatMoney(item.amount)}</li>
+              ))}
+            </ul>
           </li>
         ))}
       </ul>
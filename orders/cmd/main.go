@@ -10,17 +10,28 @@ import (
 	"syscall"
 	"time"
 
+	"sd_hw4/orders/internal/clients"
 	orders "sd_hw4/orders/internal/gen"
 	handlers "sd_hw4/orders/internal/handlers"
 	"sd_hw4/orders/internal/repositories"
 	services "sd_hw4/orders/internal/service"
+	"sd_hw4/pkg/admin"
 	"sd_hw4/pkg/config"
+	"sd_hw4/pkg/contracts"
 	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/idempotency"
+	"sd_hw4/pkg/inbox"
 	"sd_hw4/pkg/messaging"
+	"sd_hw4/pkg/outbox"
+	"sd_hw4/pkg/retention"
+	"sd_hw4/pkg/tunables"
+	"sd_hw4/pkg/webhooks"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,28 +45,32 @@ func main() {
 	logger.SetLevel(logrus.InfoLevel)
 
 	// Загрузка конфигурации
-	cfg := config.Load()
-	logger.Println(cfg)
+	cfg, err := config.LoadOrders(os.Args[1:])
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	logger.WithField("config", cfg).Info("Configuration loaded")
 
 	// Инициализация базы данных
 	logger.Println("Connecting to database...")
-	if err := db.Connect(cfg.DatabaseURL); err != nil {
+	if err := db.Connect(cfg.DB.DSN.Value()); err != nil {
 		logger.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
 	logger.Println("Database connected successfully")
 	// Выполнение миграций
-	if err := db.Migrate(cfg.MigrationsDir); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if cfg.DB.RunMigrations {
+		if err := db.Migrate(cfg.DB.MigrationsDir); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
 	}
 
 	// Инициализация RabbitMQ
-	factory := messaging.NewFactory()
 	mqConfig := messaging.ConnectionConfig{
-		URL:            cfg.RabbitMQURL,
-		MaxReconnects:  10,
-		ReconnectDelay: 5 * time.Second,
+		URL:            cfg.Broker.URL.Value(),
+		MaxReconnects:  cfg.Broker.MaxReconnects,
+		ReconnectDelay: cfg.Broker.ReconnectDelay,
 	}
 
 	mqConn := messaging.NewConnection(mqConfig)
@@ -67,24 +82,121 @@ func main() {
 	// Инициализация менеджера очередей
 	queueManager := messaging.NewQueueManager(mqConn)
 
-	// Конфигурация publisher для запросов оплаты
-	paymentRequestPub := queueManager.GetOrCreatePublisher(
-		"payment_request",
-		factory.PaymentRequestPublisher(),
-	)
-
 	// Инициализация репозиториев
 	orderRepo := repositories.NewOrderRepository(db.DB)
-	inboxRepo := repositories.NewInboxRepo(db.DB)
-	outboxRepo := repositories.NewOutboxRepository(db.DB)
+	inboxStore := inbox.NewPostgresStore(db.DB)
+	outboxStore := outbox.NewPostgresStore(db.DB)
+
+	// Уведомления Postgres будят обработчики сразу после вставки строк, опрос остается страховкой
+	notifier := db.NewNotifier(cfg.DB.DSN.Value())
+	// В режиме cdc новые строки outbox приходят из WAL, уведомления relay не нужны
+	var outboxWake <-chan struct{}
+	if cfg.Outbox.Mode != "cdc" {
+		outboxWake = subscribe(logger, notifier, outbox.NotifyChannel)
+	}
+	statusEventsWake := subscribe(logger, notifier, services.StatusEventsChannel)
+	webhooksWake := subscribe(logger, notifier, webhooks.NotifyChannel)
+
+	// Настраиваемые во время работы параметры фоновых обработчиков; новые значения проверяются
+	// вместе с остальной конфигурацией, как при старте
+	processors := tunables.NewRegistry(func(candidate map[string]config.ProcessorConfig) error {
+		next := *cfg
+		for name, processor := range candidate {
+			next.Processors.Set(name, processor)
+		}
+		return next.Validate()
+	})
+	outboxTunables := processors.Register("outbox_relay", cfg.Processors.OutboxRelay)
+	inboxTunables := processors.Register("inbox", cfg.Processors.Inbox)
+	retentionTunables := processors.Register("retention", cfg.Processors.Retention)
+	paymentSweeperTunables := processors.Register("payment_sweeper", cfg.Processors.PaymentSweeper)
+	webhookTunables := processors.Register("webhook_dispatcher", cfg.Processors.WebhookDispatcher)
 
 	// Инициализация сервисов
-	orderService := services.NewOrderService(orderRepo, outboxRepo, paymentRequestPub)
-	outboxService := services.NewOutboxService(outboxRepo, paymentRequestPub, 10)
-	inboxService := services.NewInboxService(inboxRepo, orderService, 10, "orders")
+	orderService := services.NewOrderService(db.DB, orderRepo, repositories.NewProductRepository(db.DB), outboxStore, cfg.Outbox.PaymentRequest)
+	// События вебхуков идут через outbox вместе с остальными сообщениями, но не в брокер,
+	// а в журнал доставок, откуда их отправляет webhookDispatcher
+	webhookStore := webhooks.NewStore(db.DB)
+	outboxRelay := outbox.NewRelay(
+		outboxStore,
+		outbox.Router{
+			Default:   mqConn,
+			Exchanges: map[string]outbox.Publisher{webhooks.Exchange: webhooks.NewPublisher(webhookStore)},
+		},
+		outboxTunables,
+		outbox.RelayOptions{
+			Owner: cfg.InstanceID,
+			Lease: cfg.Outbox.Lease,
+			Retry: cfg.Outbox.Retry.Policy(),
+			Wake:  outboxWake,
+			Hooks: outbox.NewMetrics("orders", prometheus.DefaultRegisterer).Hooks(),
+		},
+	)
+	outbox.RegisterStatusGauge("orders", prometheus.DefaultRegisterer, outboxStore)
+
+	webhookDispatcher := webhooks.NewDispatcher(webhookStore, webhookTunables, webhooks.DispatcherOptions{
+		Owner:          cfg.InstanceID,
+		Lease:          cfg.Webhooks.Lease,
+		RequestTimeout: cfg.Webhooks.RequestTimeout,
+		Retry:          cfg.Webhooks.Retry.Policy(),
+		// Адреса внутренней сети запрещены, если это не включено явно для локальной отладки
+		AllowPrivateAddresses: cfg.Webhooks.AllowPrivateAddresses,
+		Wake:                  webhooksWake,
+	})
+
+	// Очистка отправленных сообщений outbox, обработанных сообщений inbox, устаревших ключей идемпотентности
+	// и завершенных доставок вебхуков
+	retentionPolicies := []retention.Policy{
+		retention.OutboxPolicy(retention.Mode(cfg.Retention.Outbox.Mode), cfg.Retention.Outbox.MaxAge),
+		retention.InboxPolicy(retention.Mode(cfg.Retention.Inbox.Mode), cfg.Retention.Inbox.MaxAge),
+		retention.IdempotencyPolicy(cfg.Idempotency.TTL),
+		retention.WebhookDeliveryPolicy(retention.Mode(cfg.Webhooks.Retention.Mode), cfg.Webhooks.Retention.MaxAge),
+	}
+	retentionCleaner := retention.NewCleaner(
+		db.DB,
+		retentionTunables,
+		retention.NewMetrics("orders", prometheus.DefaultRegisterer, db.DB, retention.Tables(retentionPolicies...)...).Hooks(),
+		retentionPolicies...,
+	)
+
+	// Заказы, не дождавшиеся результата оплаты, завершаются по журналу payments или отменяются
+	var paymentOutcomes services.PaymentOutcomes
+	if cfg.PaymentTimeout.PaymentsURL != "" {
+		paymentOutcomes = clients.NewPaymentsClient(cfg.PaymentTimeout.PaymentsURL, cfg.PaymentTimeout.RequestTimeout)
+	} else {
+		logger.Warn("payment_timeout.payments_url is not set: overdue orders are canceled without asking payments")
+	}
+	paymentSweeper := services.NewPaymentSweeper(orderService, paymentOutcomes, cfg.PaymentTimeout.Deadline, paymentSweeperTunables)
+	inboxService := services.NewInboxService(inboxStore)
+	// Переходы статусов из истории, зафиксированные любой репликой, рассылаются потокам SSE
+	statusEvents := services.NewStatusEvents(orderRepo, statusEventsWake)
 
 	// Инициализация обработчика сообщений
-	consumerHandler := handlers.NewConsumerHandler(inboxService)
+	consumerHandler := handlers.NewConsumerHandler(inboxService, cfg.Broker.Consumer)
+
+	// Обработчики входящих сообщений по очереди и типу; без обработчика сохраненные
+	// консьюмером сообщения никто не обработает, поэтому сервис не запускается
+	dispatcher := inbox.NewDispatcher()
+	dispatcher.Register(consumerHandler.InboxQueue(), contracts.TypePaymentResult, inbox.JSONMessage(orderService.ProcessPaymentResult))
+	dispatcher.Register(consumerHandler.InboxQueue(), contracts.TypeRefundResult, inbox.JSONMessage(orderService.ProcessRefundResult))
+	if err := dispatcher.Validate(consumerHandler.InboxQueue()); err != nil {
+		logger.Fatalf("Invalid inbox configuration: %v", err)
+	}
+	inboxProcessors := dispatcher.Processors(
+		db.DB,
+		inboxStore,
+		inboxTunables,
+		inbox.ProcessorOptions{
+			Owner:    cfg.InstanceID,
+			Lease:    cfg.Inbox.Lease,
+			Ordering: inbox.Ordering(cfg.Inbox.Ordering),
+			Retry:    cfg.Inbox.Retry.Policy(),
+			Hooks:    inbox.NewMetrics("orders", prometheus.DefaultRegisterer).Hooks(),
+		},
+		// Каждый процессор подписывается на уведомления сам: уведомление будит всех подписчиков
+		func(string) <-chan struct{} { return subscribe(logger, notifier, inbox.NotifyChannel) },
+	)
+	inbox.RegisterStateGauge("orders", prometheus.DefaultRegisterer, inboxStore)
 
 	// Создание Echo сервера
 	e := echo.New()
@@ -116,19 +228,86 @@ func main() {
 		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
 	}))
 
+	// Повтор запроса с тем же Idempotency-Key возвращает сохраненный ответ, а не выполняет его снова
+	e.Use(idempotency.Middleware(idempotency.NewStore(db.DB), idempotency.Options{
+		Routes:      []string{"/create/:user_id"},
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Idempotency.LockTimeout,
+	}))
+
 	// Инициализация хендлеров
-	orderHandler := handlers.NewOrderHandler(orderService)
+	orderHandler := handlers.NewOrderHandler(orderService, statusEvents, webhookStore, cfg.Webhooks.AllowPrivateAddresses)
+	// Открытые потоки SSE закрываются при остановке сервера, иначе Shutdown будет их ждать
+	e.Server.RegisterOnShutdown(statusEvents.Close)
 
 	// Регистрация маршрутов из OpenAPI
 	orders.RegisterHandlers(e, orderHandler)
 
+	// Административные маршруты доступны только с токеном; без токена они не регистрируются
+	if cfg.Admin.Token != "" {
+		auditLog := admin.NewAuditLog(db.DB)
+		adminGroup := e.Group("/admin", admin.RequireToken(cfg.Admin.Token.Value()))
+		tunables.NewHandler(processors).RegisterRoutes(adminGroup)
+		outbox.NewHandler(outboxStore, auditLog).RegisterRoutes(adminGroup)
+		inbox.NewHandler(db.DB, inboxStore, auditLog, dispatcher).RegisterRoutes(adminGroup)
+		auditLog.RegisterRoutes(adminGroup)
+	} else {
+		logger.Warn("Admin API is disabled: admin.token is not set")
+	}
+
+	// Перечитывание конфигурации по SIGHUP применяет параметры обработчиков без перезапуска
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			reloaded, err := config.LoadOrders(os.Args[1:])
+			if err != nil {
+				logger.WithError(err).Error("Failed to reload configuration, keeping current settings")
+				continue
+			}
+			if err := outboxTunables.Apply(reloaded.Processors.OutboxRelay, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply outbox relay settings")
+			}
+			if err := retentionTunables.Apply(reloaded.Processors.Retention, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply retention settings")
+			}
+			if err := inboxTunables.Apply(reloaded.Processors.Inbox, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply inbox processor settings")
+			}
+			if err := paymentSweeperTunables.Apply(reloaded.Processors.PaymentSweeper, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply payment sweeper settings")
+			}
+			if err := webhookTunables.Apply(reloaded.Processors.WebhookDispatcher, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply webhook dispatcher settings")
+			}
+			logger.WithField("processors", processors.Snapshot()).Info("Configuration reloaded")
+		}
+	}()
+
 	// Контекст для фоновых задач
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go notifier.Run(ctx)
+
 	// Запуск фоновых процессов
-	go outboxService.StartProcessor(ctx)
-	go inboxService.StartProcessor(ctx)
+	go outboxRelay.Run(ctx)
+	if cfg.Outbox.Mode == "cdc" {
+		// Опросный relay остается для повторов и строк, пропущенных потоком изменений
+		cdcRelay := outbox.NewCDCRelay(outboxRelay, db.DB, outbox.CDCOptions{
+			DSN:         cfg.DB.DSN.Value(),
+			Slot:        cfg.Outbox.CDC.Slot,
+			Publication: cfg.Outbox.CDC.Publication,
+		})
+		go cdcRelay.Run(ctx)
+	}
+	go retentionCleaner.Run(ctx)
+	go paymentSweeper.Run(ctx)
+	go statusEvents.Run(ctx)
+	go webhookDispatcher.Run(ctx)
+	for _, processor := range inboxProcessors {
+		go processor.Run(ctx)
+	}
 
 	// Запуск консьюмера RabbitMQ
 	go func() {
@@ -136,6 +315,9 @@ func main() {
 			log.Printf("Consumer stopped with error: %v", err)
 		}
 	}()
+	// Метрики Prometheus
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
 	// Health check endpoint
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{
@@ -144,7 +326,7 @@ func main() {
 		})
 	})
 
-	if err := e.Start(":" + cfg.ServerPort); err != nil && err != http.ErrServerClosed {
+	if err := e.Start(":" + cfg.HTTP.Port); err != nil && err != http.ErrServerClosed {
 		logger.Fatal("Failed to start HTTP server:", err)
 	}
 
@@ -157,7 +339,7 @@ func main() {
 	logger.Info("Shutting down server...")
 
 	// Graceful shutdown с таймаутом
-	ctx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, shutdownCancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer shutdownCancel()
 
 	// Остановка HTTP сервера
@@ -170,8 +352,18 @@ func main() {
 	queueManager.StopAllConsumers()
 
 	// Откат миграций при необходимости
-	db.Rollback(cfg.MigrationsDir)
+	db.Rollback(cfg.DB.MigrationsDir)
 
 	time.Sleep(2 * time.Second)
 	logger.Info("Server stopped gracefully")
 }
+
+// subscribe подписывается на уведомления канала; при ошибке обработчик работает только по таймеру.
+func subscribe(logger *logrus.Logger, notifier *db.Notifier, channel string) <-chan struct{} {
+	wake, err := notifier.Subscribe(channel)
+	if err != nil {
+		logger.WithError(err).Warnf("Failed to listen on %s, falling back to polling", channel)
+		return nil
+	}
+	return wake
+}
@@ -0,0 +1,40 @@
+// webhook-receiver — локальный получатель вебхуков для проверки интеграции: принимает события,
+// проверяет подпись и печатает их. Запуск:
+//
+//	WEBHOOK_SECRET=<секрет адреса> go run ./orders/cmd/webhook-receiver -addr :9090
+//
+// и регистрация адреса http://host.docker.internal:9090/ (или http://localhost:9090/,
+// если orders запущен не в контейнере).
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"sd_hw4/pkg/webhooks"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "endpoint secret (WEBHOOK_SECRET)")
+	fail := flag.Bool("fail", false, "answer 500 to every event to exercise retries")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("secret is required: set -secret or WEBHOOK_SECRET")
+	}
+
+	http.Handle("/", webhooks.Receiver(*secret, webhooks.DefaultTolerance, func(event webhooks.Event) error {
+		log.Printf("%s %s at %s: %s", event.Type, event.ID, event.CreatedAt.Format("15:04:05"), event.Data)
+		if *fail {
+			return errors.New("failure requested with -fail")
+		}
+		return nil
+	}))
+
+	log.Printf("Listening for webhooks on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
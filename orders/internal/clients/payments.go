@@ -0,0 +1,60 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sd_hw4/pkg/contracts"
+
+	"github.com/google/uuid"
+)
+
+// PaymentsClient запрашивает у payments журнал оплат заказа (GET /operations/{order_id}).
+type PaymentsClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewPaymentsClient(baseURL string, timeout time.Duration) *PaymentsClient {
+	return &PaymentsClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Debit возвращает списание по заказу или nil, если payments еще не обработал запрос на оплату.
+// Записанное списание окончательно, в том числе неудачное.
+func (c *PaymentsClient) Debit(ctx context.Context, orderID uuid.UUID) (*contracts.PaymentOperation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/operations/"+url.PathEscape(orderID.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("payments request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("payments responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var operations []contracts.PaymentOperation
+	if err := json.NewDecoder(resp.Body).Decode(&operations); err != nil {
+		return nil, fmt.Errorf("failed to decode payments response: %w", err)
+	}
+	for _, op := range operations {
+		if op.Kind == contracts.OperationDebit {
+			return &op, nil
+		}
+	}
+	return nil, nil
+}
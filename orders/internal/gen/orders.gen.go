@@ -12,50 +12,335 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
+
+	"sd_hw4/pkg/money"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
 )
 
+// Defines values for OrderSort.
+const (
+	CreatedAtAsc  OrderSort = "created_at_asc"
+	CreatedAtDesc OrderSort = "created_at_desc"
+	PriceAsc      OrderSort = "price_asc"
+	PriceDesc     OrderSort = "price_desc"
+)
+
 // Defines values for OrderStatus.
 const (
-	Canceled OrderStatus = "canceled"
-	Finished OrderStatus = "finished"
-	New      OrderStatus = "new"
+	Canceled       OrderStatus = "canceled"
+	Finished       OrderStatus = "finished"
+	New            OrderStatus = "new"
+	PaymentPending OrderStatus = "payment_pending"
+	RefundPending  OrderStatus = "refund_pending"
+	Refunded       OrderStatus = "refunded"
+)
+
+// Defines values for ReasonCode.
+const (
+	ReasonCodeBillNotFound      ReasonCode = "bill_not_found"
+	ReasonCodeCanceledByUser    ReasonCode = "canceled_by_user"
+	ReasonCodeCurrencyMismatch  ReasonCode = "currency_mismatch"
+	ReasonCodeInsufficientFunds ReasonCode = "insufficient_funds"
+	ReasonCodeInvalidRequest    ReasonCode = "invalid_request"
+	ReasonCodeNoActiveBill      ReasonCode = "no_active_bill"
+	ReasonCodeNoBill            ReasonCode = "no_bill"
+	ReasonCodeNotPaid           ReasonCode = "not_paid"
+	ReasonCodeOrderCanceled     ReasonCode = "order_canceled"
+	ReasonCodePaymentTimeout    ReasonCode = "payment_timeout"
+	ReasonCodeUnknown           ReasonCode = "unknown"
+)
+
+// Defines values for WebhookDeliveryStatus.
+const (
+	Dead      WebhookDeliveryStatus = "dead"
+	Delivered WebhookDeliveryStatus = "delivered"
+	Failed    WebhookDeliveryStatus = "failed"
+	Pending   WebhookDeliveryStatus = "pending"
+)
+
+// Defines values for WebhookEventType.
+const (
+	WebhookEventTypeOrderCanceled WebhookEventType = "order.canceled"
+	WebhookEventTypeOrderCreated  WebhookEventType = "order.created"
+	WebhookEventTypeOrderPaid     WebhookEventType = "order.paid"
+	WebhookEventTypeOrderRefunded WebhookEventType = "order.refunded"
 )
 
+// CancelReason Почему заказ отменен; есть у заказов в статусах canceled, refund_pending и refunded
+type CancelReason struct {
+	// Code Стандартный код причины отмены заказа или отказа в оплате. Есть только у переходов, которые отменяют заказ (canceled, refund_pending). insufficient_funds — не хватает средств на счете (стоит предложить пополнить баланс), no_bill / no_active_bill — нет счета или активного счета, canceled_by_user — отменен пользователем, payment_timeout — результат оплаты не пришел вовремя, unknown — причина без кода
+	Code ReasonCode `json:"code"`
+
+	// Message Текст причины, например insufficient funds
+	Message string `json:"message"`
+}
+
+// CreateOrderRequest defines model for CreateOrderRequest.
+type CreateOrderRequest struct {
+	// Description Описание заказа
+	Description *string `json:"description,omitempty"`
+
+	// Items Позиции заказа; повторяющиеся артикулы складываются
+	Items []OrderItemRequest `json:"items"`
+}
+
+// Money Денежная сумма. amount — десятичная строка с двумя знаками после запятой, currency — код валюты ISO 4217
+type Money = money.Money
+
 // Order defines model for Order.
 type Order struct {
-	// Amount ╨б╤Г╨╝╨╝╨░ ╨╖╨░╨║╨░╨╖╨░
-	Amount float32 `json:"amount"`
+	// Amount Денежная сумма. amount — десятичная строка с двумя знаками после запятой, currency — код валюты ISO 4217
+	Amount Money `json:"amount"`
 
-	// Description ╨Ю╨┐╨╕╤Б╨░╨╜╨╕╨╡ ╨╖╨░╨║╨░╨╖╨░
+	// CancelReason Почему заказ отменен; есть у заказов в статусах canceled, refund_pending и refunded
+	CancelReason *CancelReason `json:"cancel_reason,omitempty"`
+
+	// CreatedAt Время создания заказа
+	CreatedAt time.Time `json:"created_at"`
+
+	// Description Описание заказа
 	Description string `json:"description"`
 
-	// Id ╨г╨╜╨╕╨║╨░╨╗╤М╨╜╤Л╨╣ ╨╕╨┤╨╡╨╜╤В╨╕╤Д╨╕╨║╨░╤В╨╛╤А ╨╖╨░╨║╨░╨╖╨░
+	// Id Уникальный идентификатор заказа
 	Id string `json:"id"`
 
-	// Status ╨б╤В╨░╤В╤Г╤Б ╨╖╨░╨║╨░╨╖╨░
+	// Items Позиции заказа
+	Items []OrderItem `json:"items"`
+
+	// Status Статус заказа: new → payment_pending → finished (оплачен) или canceled; finished → refund_pending → refunded
 	Status OrderStatus `json:"status"`
 
-	// UserId ID ╨┐╨╛╨╗╤М╨╖╨╛╨▓╨░╤В╨╡╨╗╤П
+	// UserId ID пользователя
 	UserId string `json:"user_id"`
 }
 
-// OrderStatus ╨б╤В╨░╤В╤Г╤Б ╨╖╨░╨║╨░╨╖╨░
+// OrderItem defines model for OrderItem.
+type OrderItem struct {
+	// Amount Денежная сумма. amount — десятичная строка с двумя знаками после запятой, currency — код валюты ISO 4217
+	Amount Money `json:"amount"`
+
+	// Name Название товара на момент заказа
+	Name string `json:"name"`
+
+	// ProductId ID товара
+	ProductId string `json:"product_id"`
+
+	// Quantity Количество
+	Quantity int `json:"quantity"`
+
+	// Sku Артикул товара
+	Sku string `json:"sku"`
+
+	// UnitPrice Денежная сумма. amount — десятичная строка с двумя знаками после запятой, currency — код валюты ISO 4217
+	UnitPrice Money `json:"unit_price"`
+}
+
+// OrderItemRequest defines model for OrderItemRequest.
+type OrderItemRequest struct {
+	// Quantity Количество
+	Quantity int `json:"quantity"`
+
+	// Sku Артикул товара
+	Sku string `json:"sku"`
+}
+
+// OrderPage defines model for OrderPage.
+type OrderPage struct {
+	// NextCursor Курсор следующей страницы; отсутствует на последней странице
+	NextCursor *string `json:"next_cursor,omitempty"`
+	Orders     []Order `json:"orders"`
+}
+
+// OrderSort Порядок заказов; при равных значениях заказы упорядочиваются по ID
+type OrderSort string
+
+// OrderStatus Статус заказа: new → payment_pending → finished (оплачен) или canceled; finished → refund_pending → refunded
 type OrderStatus string
 
-// PostCreateUserIdJSONBody defines parameters for PostCreateUserId.
-type PostCreateUserIdJSONBody struct {
-	// Amount ╨б╤Г╨╝╨╝╨░ ╨╖╨░╨║╨░╨╖╨░
-	Amount float32 `json:"amount"`
+// OrderStatusEvent Данные события status в потоке /orders/{user_id}/events
+type OrderStatusEvent struct {
+	CreatedAt time.Time `json:"created_at"`
 
-	// Description ╨Ю╨┐╨╕╤Б╨░╨╜╨╕╨╡ ╨╖╨░╨║╨░╨╖╨░
-	Description string `json:"description"`
+	// From Статус заказа: new → payment_pending → finished (оплачен) или canceled; finished → refund_pending → refunded
+	From *OrderStatus `json:"from,omitempty"`
+
+	// Id ID записи истории, он же id события
+	Id      int64  `json:"id"`
+	OrderId string `json:"order_id"`
+
+	// Reason Причина перехода
+	Reason string `json:"reason"`
+
+	// ReasonCode Стандартный код причины отмены заказа или отказа в оплате. Есть только у переходов, которые отменяют заказ (canceled, refund_pending). insufficient_funds — не хватает средств на счете (стоит предложить пополнить баланс), no_bill / no_active_bill — нет счета или активного счета, canceled_by_user — отменен пользователем, payment_timeout — результат оплаты не пришел вовремя, unknown — причина без кода
+	ReasonCode *ReasonCode `json:"reason_code,omitempty"`
+
+	// To Статус заказа: new → payment_pending → finished (оплачен) или canceled; finished → refund_pending → refunded
+	To OrderStatus `json:"to"`
+}
+
+// Product defines model for Product.
+type Product struct {
+	// Id ID товара
+	Id string `json:"id"`
+
+	// Name Название товара
+	Name string `json:"name"`
+
+	// Price Денежная сумма. amount — десятичная строка с двумя знаками после запятой, currency — код валюты ISO 4217
+	Price Money `json:"price"`
+
+	// Sku Артикул товара
+	Sku string `json:"sku"`
+}
+
+// ReasonCode Стандартный код причины отмены заказа или отказа в оплате. Есть только у переходов, которые отменяют заказ (canceled, refund_pending). insufficient_funds — не хватает средств на счете (стоит предложить пополнить баланс), no_bill / no_active_bill — нет счета или активного счета, canceled_by_user — отменен пользователем, payment_timeout — результат оплаты не пришел вовремя, unknown — причина без кода
+type ReasonCode string
+
+// StatusChange defines model for StatusChange.
+type StatusChange struct {
+	// CreatedAt Время перехода
+	CreatedAt time.Time `json:"created_at"`
+
+	// From Статус заказа: new → payment_pending → finished (оплачен) или canceled; finished → refund_pending → refunded
+	From *OrderStatus `json:"from,omitempty"`
+
+	// MessageId ID сообщения, вызвавшего переход
+	MessageId *string `json:"message_id,omitempty"`
+
+	// Reason Причина перехода
+	Reason string `json:"reason"`
+
+	// ReasonCode Стандартный код причины отмены заказа или отказа в оплате. Есть только у переходов, которые отменяют заказ (canceled, refund_pending). insufficient_funds — не хватает средств на счете (стоит предложить пополнить баланс), no_bill / no_active_bill — нет счета или активного счета, canceled_by_user — отменен пользователем, payment_timeout — результат оплаты не пришел вовремя, unknown — причина без кода
+	ReasonCode *ReasonCode `json:"reason_code,omitempty"`
+
+	// To Статус заказа: new → payment_pending → finished (оплачен) или canceled; finished → refund_pending → refunded
+	To OrderStatus `json:"to"`
+}
+
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	// Attempts Число попыток с последней постановки в очередь
+	Attempts    int        `json:"attempts"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	EndpointId  string     `json:"endpoint_id"`
+
+	// Event Тело запроса к адресу вебхуков
+	Event         WebhookEvent `json:"event"`
+	Id            int64        `json:"id"`
+	LastAttemptAt *time.Time   `json:"last_attempt_at,omitempty"`
+	LastError     *string      `json:"last_error,omitempty"`
+
+	// LastStatusCode Код ответа получателя на последнюю попытку; отсутствует, если ответа не было
+	LastStatusCode *int `json:"last_status_code,omitempty"`
+
+	// NextAttemptAt Время следующей попытки для pending и failed
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+
+	// Status pending — ждет отправки, failed — попытка не удалась и будет повторена, delivered — получатель ответил 2xx, dead — попытки исчерпаны
+	Status WebhookDeliveryStatus `json:"status"`
+}
+
+// WebhookDeliveryStatus pending — ждет отправки, failed — попытка не удалась и будет повторена, delivered — получатель ответил 2xx, dead — попытки исчерпаны
+type WebhookDeliveryStatus string
+
+// WebhookEndpoint defines model for WebhookEndpoint.
+type WebhookEndpoint struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// Events Пустой список — все события
+	Events []WebhookEventType `json:"events"`
+	Id     string             `json:"id"`
+
+	// Secret Секрет подписи; возвращается только при регистрации
+	Secret *string `json:"secret,omitempty"`
+	Url    string  `json:"url"`
+}
+
+// WebhookEndpointRequest defines model for WebhookEndpointRequest.
+type WebhookEndpointRequest struct {
+	// Events События, на которые подписан адрес; пустой список или его отсутствие — все события
+	Events *[]WebhookEventType `json:"events,omitempty"`
+
+	// Secret Секрет подписи; если не задан, генерируется сервисом
+	Secret *string `json:"secret,omitempty"`
+
+	// Url Адрес http(s), на который отправляются события
+	Url string `json:"url"`
+}
+
+// WebhookEvent Тело запроса к адресу вебхуков
+type WebhookEvent struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// Data Для событий заказа — order_id, user_id, status, amount, description, reason, timestamp; у order.canceled и order.refunded также reason_code (см. ReasonCode)
+	Data map[string]interface{} `json:"data"`
+
+	// Id ID события; одинаков во всех доставках и повторах, по нему отбрасываются дубли
+	Id string `json:"id"`
+
+	// Type Тип события (WebhookEventType или webhook.ping)
+	Type string `json:"type"`
+}
+
+// WebhookEventType Тип события: order.created — заказ создан и ждет оплаты, order.paid — оплачен, order.canceled — отменен до оплаты, order.refunded — оплата возвращена
+type WebhookEventType string
+
+// PostCreateUserIdParams defines parameters for PostCreateUserId.
+type PostCreateUserIdParams struct {
+	// IdempotencyKey Ключ идемпотентности (например, UUID). Повтор запроса с тем же ключом и телом возвращает сохраненный ответ с заголовком Idempotent-Replayed: true
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// GetOrdersUserIdParams defines parameters for GetOrdersUserId.
+type GetOrdersUserIdParams struct {
+	// Status Только заказы в этих статусах (параметр можно повторять)
+	Status *[]OrderStatus `form:"status,omitempty" json:"status,omitempty"`
+
+	// CreatedFrom Заказы, созданные не раньше этого времени
+	CreatedFrom *time.Time `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Заказы, созданные раньше этого времени
+	CreatedTo *time.Time `form:"created_to,omitempty" json:"created_to,omitempty"`
+
+	// MinPrice Минимальная сумма заказа в RUB, не больше двух знаков после запятой (например, 150.50)
+	MinPrice *string `form:"min_price,omitempty" json:"min_price,omitempty"`
+
+	// MaxPrice Максимальная сумма заказа в RUB, не больше двух знаков после запятой (например, 150.50)
+	MaxPrice *string    `form:"max_price,omitempty" json:"max_price,omitempty"`
+	Sort     *OrderSort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Limit Размер страницы
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor next_cursor предыдущей страницы
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// GetOrdersUserIdEventsParams defines parameters for GetOrdersUserIdEvents.
+type GetOrdersUserIdEventsParams struct {
+	// LastEventId То же, что Last-Event-ID, для клиентов, которые не могут передать заголовок
+	LastEventId *int64 `form:"last_event_id,omitempty" json:"last_event_id,omitempty"`
+
+	// LastEventID id последнего полученного события
+	LastEventID *int64 `json:"Last-Event-ID,omitempty"`
+}
+
+// GetWebhooksUserIdEndpointIdDeliveriesParams defines parameters for GetWebhooksUserIdEndpointIdDeliveries.
+type GetWebhooksUserIdEndpointIdDeliveriesParams struct {
+	// Status Только доставки в этом статусе
+	Status *WebhookDeliveryStatus `form:"status,omitempty" json:"status,omitempty"`
+	Limit  *int                   `form:"limit,omitempty" json:"limit,omitempty"`
 }
 
 // PostCreateUserIdJSONRequestBody defines body for PostCreateUserId for application/json ContentType.
-type PostCreateUserIdJSONRequestBody PostCreateUserIdJSONBody
+type PostCreateUserIdJSONRequestBody = CreateOrderRequest
+
+// PostWebhooksUserIdJSONRequestBody defines body for PostWebhooksUserId for application/json ContentType.
+type PostWebhooksUserIdJSONRequestBody = WebhookEndpointRequest
 
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
@@ -130,20 +415,100 @@ func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
 
 // The interface specification for the client above.
 type ClientInterface interface {
+	// PostCancelOrderId request
+	PostCancelOrderId(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// PostCreateUserIdWithBody request with any body
-	PostCreateUserIdWithBody(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+	PostCreateUserIdWithBody(ctx context.Context, userId string, params *PostCreateUserIdParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
-	PostCreateUserId(ctx context.Context, userId string, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+	PostCreateUserId(ctx context.Context, userId string, params *PostCreateUserIdParams, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOrdersOrderIdHistory request
+	GetOrdersOrderIdHistory(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	// GetOrdersUserId request
-	GetOrdersUserId(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+	GetOrdersUserId(ctx context.Context, userId string, params *GetOrdersUserIdParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOrdersUserIdEvents request
+	GetOrdersUserIdEvents(ctx context.Context, userId string, params *GetOrdersUserIdEventsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetProducts request
+	GetProducts(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	// GetStatusOrderId request
 	GetStatusOrderId(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetWebhooksUserId request
+	GetWebhooksUserId(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostWebhooksUserIdWithBody request with any body
+	PostWebhooksUserIdWithBody(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostWebhooksUserId(ctx context.Context, userId string, body PostWebhooksUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteWebhooksUserIdEndpointId request
+	DeleteWebhooksUserIdEndpointId(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetWebhooksUserIdEndpointIdDeliveries request
+	GetWebhooksUserIdEndpointIdDeliveries(ctx context.Context, userId string, endpointId string, params *GetWebhooksUserIdEndpointIdDeliveriesParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver request
+	PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(ctx context.Context, userId string, endpointId string, deliveryId int64, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostWebhooksUserIdEndpointIdPing request
+	PostWebhooksUserIdEndpointIdPing(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) PostCancelOrderId(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostCancelOrderIdRequest(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostCreateUserIdWithBody(ctx context.Context, userId string, params *PostCreateUserIdParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostCreateUserIdRequestWithBody(c.Server, userId, params, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostCreateUserId(ctx context.Context, userId string, params *PostCreateUserIdParams, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostCreateUserIdRequest(c.Server, userId, params, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetOrdersOrderIdHistory(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOrdersOrderIdHistoryRequest(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-func (c *Client) PostCreateUserIdWithBody(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostCreateUserIdRequestWithBody(c.Server, userId, contentType, body)
+func (c *Client) GetOrdersUserId(ctx context.Context, userId string, params *GetOrdersUserIdParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOrdersUserIdRequest(c.Server, userId, params)
 	if err != nil {
 		return nil, err
 	}
@@ -154,8 +519,8 @@ func (c *Client) PostCreateUserIdWithBody(ctx context.Context, userId string, co
 	return c.Client.Do(req)
 }
 
-func (c *Client) PostCreateUserId(ctx context.Context, userId string, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewPostCreateUserIdRequest(c.Server, userId, body)
+func (c *Client) GetOrdersUserIdEvents(ctx context.Context, userId string, params *GetOrdersUserIdEventsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOrdersUserIdEventsRequest(c.Server, userId, params)
 	if err != nil {
 		return nil, err
 	}
@@ -166,8 +531,8 @@ func (c *Client) PostCreateUserId(ctx context.Context, userId string, body PostC
 	return c.Client.Do(req)
 }
 
-func (c *Client) GetOrdersUserId(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
-	req, err := NewGetOrdersUserIdRequest(c.Server, userId)
+func (c *Client) GetProducts(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetProductsRequest(c.Server)
 	if err != nil {
 		return nil, err
 	}
@@ -190,24 +555,97 @@ func (c *Client) GetStatusOrderId(ctx context.Context, orderId string, reqEditor
 	return c.Client.Do(req)
 }
 
-// NewPostCreateUserIdRequest calls the generic PostCreateUserId builder with application/json body
-func NewPostCreateUserIdRequest(server string, userId string, body PostCreateUserIdJSONRequestBody) (*http.Request, error) {
-	var bodyReader io.Reader
-	buf, err := json.Marshal(body)
+func (c *Client) GetWebhooksUserId(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetWebhooksUserIdRequest(c.Server, userId)
 	if err != nil {
 		return nil, err
 	}
-	bodyReader = bytes.NewReader(buf)
-	return NewPostCreateUserIdRequestWithBody(server, userId, "application/json", bodyReader)
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
 }
 
-// NewPostCreateUserIdRequestWithBody generates requests for PostCreateUserId with any type of body
-func NewPostCreateUserIdRequestWithBody(server string, userId string, contentType string, body io.Reader) (*http.Request, error) {
+func (c *Client) PostWebhooksUserIdWithBody(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostWebhooksUserIdRequestWithBody(c.Server, userId, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostWebhooksUserId(ctx context.Context, userId string, body PostWebhooksUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostWebhooksUserIdRequest(c.Server, userId, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DeleteWebhooksUserIdEndpointId(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteWebhooksUserIdEndpointIdRequest(c.Server, userId, endpointId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetWebhooksUserIdEndpointIdDeliveries(ctx context.Context, userId string, endpointId string, params *GetWebhooksUserIdEndpointIdDeliveriesParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetWebhooksUserIdEndpointIdDeliveriesRequest(c.Server, userId, endpointId, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(ctx context.Context, userId string, endpointId string, deliveryId int64, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverRequest(c.Server, userId, endpointId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostWebhooksUserIdEndpointIdPing(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostWebhooksUserIdEndpointIdPingRequest(c.Server, userId, endpointId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+// NewPostCancelOrderIdRequest generates requests for PostCancelOrderId
+func NewPostCancelOrderIdRequest(server string, orderId string) (*http.Request, error) {
 	var err error
 
 	var pathParam0 string
 
-	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
 	if err != nil {
 		return nil, err
 	}
@@ -217,7 +655,7 @@ func NewPostCreateUserIdRequestWithBody(server string, userId string, contentTyp
 		return nil, err
 	}
 
-	operationPath := fmt.Sprintf("/create/%s", pathParam0)
+	operationPath := fmt.Sprintf("/cancel/%s", pathParam0)
 	if operationPath[0] == '/' {
 		operationPath = "." + operationPath
 	}
@@ -227,18 +665,27 @@ func NewPostCreateUserIdRequestWithBody(server string, userId string, contentTyp
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", queryURL.String(), body)
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Content-Type", contentType)
-
 	return req, nil
 }
 
-// NewGetOrdersUserIdRequest generates requests for GetOrdersUserId
-func NewGetOrdersUserIdRequest(server string, userId string) (*http.Request, error) {
+// NewPostCreateUserIdRequest calls the generic PostCreateUserId builder with application/json body
+func NewPostCreateUserIdRequest(server string, userId string, params *PostCreateUserIdParams, body PostCreateUserIdJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostCreateUserIdRequestWithBody(server, userId, params, "application/json", bodyReader)
+}
+
+// NewPostCreateUserIdRequestWithBody generates requests for PostCreateUserId with any type of body
+func NewPostCreateUserIdRequestWithBody(server string, userId string, params *PostCreateUserIdParams, contentType string, body io.Reader) (*http.Request, error) {
 	var err error
 
 	var pathParam0 string
@@ -253,7 +700,7 @@ func NewGetOrdersUserIdRequest(server string, userId string) (*http.Request, err
 		return nil, err
 	}
 
-	operationPath := fmt.Sprintf("/orders/%s", pathParam0)
+	operationPath := fmt.Sprintf("/create/%s", pathParam0)
 	if operationPath[0] == '/' {
 		operationPath = "." + operationPath
 	}
@@ -263,16 +710,33 @@ func NewGetOrdersUserIdRequest(server string, userId string) (*http.Request, err
 		return nil, err
 	}
 
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	req, err := http.NewRequest("POST", queryURL.String(), body)
 	if err != nil {
 		return nil, err
 	}
 
+	req.Header.Add("Content-Type", contentType)
+
+	if params != nil {
+
+		if params.IdempotencyKey != nil {
+			var headerParam0 string
+
+			headerParam0, err = runtime.StyleParamWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, *params.IdempotencyKey)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Idempotency-Key", headerParam0)
+		}
+
+	}
+
 	return req, nil
 }
 
-// NewGetStatusOrderIdRequest generates requests for GetStatusOrderId
-func NewGetStatusOrderIdRequest(server string, orderId string) (*http.Request, error) {
+// NewGetOrdersOrderIdHistoryRequest generates requests for GetOrdersOrderIdHistory
+func NewGetOrdersOrderIdHistoryRequest(server string, orderId string) (*http.Request, error) {
 	var err error
 
 	var pathParam0 string
@@ -287,7 +751,7 @@ func NewGetStatusOrderIdRequest(server string, orderId string) (*http.Request, e
 		return nil, err
 	}
 
-	operationPath := fmt.Sprintf("/status/%s", pathParam0)
+	operationPath := fmt.Sprintf("/orders/%s/history", pathParam0)
 	if operationPath[0] == '/' {
 		operationPath = "." + operationPath
 	}
@@ -305,234 +769,1412 @@ func NewGetStatusOrderIdRequest(server string, orderId string) (*http.Request, e
 	return req, nil
 }
 
-func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
-	for _, r := range c.RequestEditors {
-		if err := r(ctx, req); err != nil {
-			return err
-		}
-	}
-	for _, r := range additionalEditors {
-		if err := r(ctx, req); err != nil {
-			return err
+// NewGetOrdersUserIdRequest generates requests for GetOrdersUserId
+func NewGetOrdersUserIdRequest(server string, userId string, params *GetOrdersUserIdParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/orders/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Status != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "status", runtime.ParamLocationQuery, *params.Status); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.CreatedFrom != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "created_from", runtime.ParamLocationQuery, *params.CreatedFrom); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.CreatedTo != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "created_to", runtime.ParamLocationQuery, *params.CreatedTo); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.MinPrice != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "min_price", runtime.ParamLocationQuery, *params.MinPrice); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.MaxPrice != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "max_price", runtime.ParamLocationQuery, *params.MaxPrice); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Sort != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "sort", runtime.ParamLocationQuery, *params.Sort); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Cursor != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "cursor", runtime.ParamLocationQuery, *params.Cursor); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetOrdersUserIdEventsRequest generates requests for GetOrdersUserIdEvents
+func NewGetOrdersUserIdEventsRequest(server string, userId string, params *GetOrdersUserIdEventsParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/orders/%s/events", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.LastEventId != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "last_event_id", runtime.ParamLocationQuery, *params.LastEventId); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+
+		if params.LastEventID != nil {
+			var headerParam0 string
+
+			headerParam0, err = runtime.StyleParamWithLocation("simple", false, "Last-Event-ID", runtime.ParamLocationHeader, *params.LastEventID)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Last-Event-ID", headerParam0)
 		}
+
+	}
+
+	return req, nil
+}
+
+// NewGetProductsRequest generates requests for GetProducts
+func NewGetProductsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/products")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetStatusOrderIdRequest generates requests for GetStatusOrderId
+func NewGetStatusOrderIdRequest(server string, orderId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/status/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetWebhooksUserIdRequest generates requests for GetWebhooksUserId
+func NewGetWebhooksUserIdRequest(server string, userId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/webhooks/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPostWebhooksUserIdRequest calls the generic PostWebhooksUserId builder with application/json body
+func NewPostWebhooksUserIdRequest(server string, userId string, body PostWebhooksUserIdJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostWebhooksUserIdRequestWithBody(server, userId, "application/json", bodyReader)
+}
+
+// NewPostWebhooksUserIdRequestWithBody generates requests for PostWebhooksUserId with any type of body
+func NewPostWebhooksUserIdRequestWithBody(server string, userId string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/webhooks/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewDeleteWebhooksUserIdEndpointIdRequest generates requests for DeleteWebhooksUserIdEndpointId
+func NewDeleteWebhooksUserIdEndpointIdRequest(server string, userId string, endpointId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "endpoint_id", runtime.ParamLocationPath, endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/webhooks/%s/%s", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetWebhooksUserIdEndpointIdDeliveriesRequest generates requests for GetWebhooksUserIdEndpointIdDeliveries
+func NewGetWebhooksUserIdEndpointIdDeliveriesRequest(server string, userId string, endpointId string, params *GetWebhooksUserIdEndpointIdDeliveriesParams) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "endpoint_id", runtime.ParamLocationPath, endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/webhooks/%s/%s/deliveries", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Status != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "status", runtime.ParamLocationQuery, *params.Status); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverRequest generates requests for PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver
+func NewPostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverRequest(server string, userId string, endpointId string, deliveryId int64) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "endpoint_id", runtime.ParamLocationPath, endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam2 string
+
+	pathParam2, err = runtime.StyleParamWithLocation("simple", false, "delivery_id", runtime.ParamLocationPath, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/webhooks/%s/%s/deliveries/%s/redeliver", pathParam0, pathParam1, pathParam2)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPostWebhooksUserIdEndpointIdPingRequest generates requests for PostWebhooksUserIdEndpointIdPing
+func NewPostWebhooksUserIdEndpointIdPingRequest(server string, userId string, endpointId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "endpoint_id", runtime.ParamLocationPath, endpointId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/webhooks/%s/%s/ping", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// PostCancelOrderIdWithResponse request
+	PostCancelOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*PostCancelOrderIdResponse, error)
+
+	// PostCreateUserIdWithBodyWithResponse request with any body
+	PostCreateUserIdWithBodyWithResponse(ctx context.Context, userId string, params *PostCreateUserIdParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error)
+
+	PostCreateUserIdWithResponse(ctx context.Context, userId string, params *PostCreateUserIdParams, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error)
+
+	// GetOrdersOrderIdHistoryWithResponse request
+	GetOrdersOrderIdHistoryWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetOrdersOrderIdHistoryResponse, error)
+
+	// GetOrdersUserIdWithResponse request
+	GetOrdersUserIdWithResponse(ctx context.Context, userId string, params *GetOrdersUserIdParams, reqEditors ...RequestEditorFn) (*GetOrdersUserIdResponse, error)
+
+	// GetOrdersUserIdEventsWithResponse request
+	GetOrdersUserIdEventsWithResponse(ctx context.Context, userId string, params *GetOrdersUserIdEventsParams, reqEditors ...RequestEditorFn) (*GetOrdersUserIdEventsResponse, error)
+
+	// GetProductsWithResponse request
+	GetProductsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetProductsResponse, error)
+
+	// GetStatusOrderIdWithResponse request
+	GetStatusOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetStatusOrderIdResponse, error)
+
+	// GetWebhooksUserIdWithResponse request
+	GetWebhooksUserIdWithResponse(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*GetWebhooksUserIdResponse, error)
+
+	// PostWebhooksUserIdWithBodyWithResponse request with any body
+	PostWebhooksUserIdWithBodyWithResponse(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdResponse, error)
+
+	PostWebhooksUserIdWithResponse(ctx context.Context, userId string, body PostWebhooksUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdResponse, error)
+
+	// DeleteWebhooksUserIdEndpointIdWithResponse request
+	DeleteWebhooksUserIdEndpointIdWithResponse(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*DeleteWebhooksUserIdEndpointIdResponse, error)
+
+	// GetWebhooksUserIdEndpointIdDeliveriesWithResponse request
+	GetWebhooksUserIdEndpointIdDeliveriesWithResponse(ctx context.Context, userId string, endpointId string, params *GetWebhooksUserIdEndpointIdDeliveriesParams, reqEditors ...RequestEditorFn) (*GetWebhooksUserIdEndpointIdDeliveriesResponse, error)
+
+	// PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverWithResponse request
+	PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverWithResponse(ctx context.Context, userId string, endpointId string, deliveryId int64, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse, error)
+
+	// PostWebhooksUserIdEndpointIdPingWithResponse request
+	PostWebhooksUserIdEndpointIdPingWithResponse(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdEndpointIdPingResponse, error)
+}
+
+type PostCancelOrderIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r PostCancelOrderIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostCancelOrderIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PostCreateUserIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON201      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r PostCreateUserIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostCreateUserIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOrdersOrderIdHistoryResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]StatusChange
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOrdersOrderIdHistoryResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOrdersOrderIdHistoryResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOrdersUserIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *OrderPage
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOrdersUserIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOrdersUserIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOrdersUserIdEventsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOrdersUserIdEventsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOrdersUserIdEventsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetProductsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]Product
+}
+
+// Status returns HTTPResponse.Status
+func (r GetProductsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetProductsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetStatusOrderIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r GetStatusOrderIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetStatusOrderIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetWebhooksUserIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]WebhookEndpoint
+}
+
+// Status returns HTTPResponse.Status
+func (r GetWebhooksUserIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetWebhooksUserIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PostWebhooksUserIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON201      *WebhookEndpoint
+}
+
+// Status returns HTTPResponse.Status
+func (r PostWebhooksUserIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostWebhooksUserIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeleteWebhooksUserIdEndpointIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DeleteWebhooksUserIdEndpointIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeleteWebhooksUserIdEndpointIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetWebhooksUserIdEndpointIdDeliveriesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]WebhookDelivery
+}
+
+// Status returns HTTPResponse.Status
+func (r GetWebhooksUserIdEndpointIdDeliveriesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetWebhooksUserIdEndpointIdDeliveriesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON202      *WebhookDelivery
+}
+
+// Status returns HTTPResponse.Status
+func (r PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PostWebhooksUserIdEndpointIdPingResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON202      *WebhookDelivery
+}
+
+// Status returns HTTPResponse.Status
+func (r PostWebhooksUserIdEndpointIdPingResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostWebhooksUserIdEndpointIdPingResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// PostCancelOrderIdWithResponse request returning *PostCancelOrderIdResponse
+func (c *ClientWithResponses) PostCancelOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*PostCancelOrderIdResponse, error) {
+	rsp, err := c.PostCancelOrderId(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostCancelOrderIdResponse(rsp)
+}
+
+// PostCreateUserIdWithBodyWithResponse request with arbitrary body returning *PostCreateUserIdResponse
+func (c *ClientWithResponses) PostCreateUserIdWithBodyWithResponse(ctx context.Context, userId string, params *PostCreateUserIdParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error) {
+	rsp, err := c.PostCreateUserIdWithBody(ctx, userId, params, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostCreateUserIdResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostCreateUserIdWithResponse(ctx context.Context, userId string, params *PostCreateUserIdParams, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error) {
+	rsp, err := c.PostCreateUserId(ctx, userId, params, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostCreateUserIdResponse(rsp)
+}
+
+// GetOrdersOrderIdHistoryWithResponse request returning *GetOrdersOrderIdHistoryResponse
+func (c *ClientWithResponses) GetOrdersOrderIdHistoryWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetOrdersOrderIdHistoryResponse, error) {
+	rsp, err := c.GetOrdersOrderIdHistory(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOrdersOrderIdHistoryResponse(rsp)
+}
+
+// GetOrdersUserIdWithResponse request returning *GetOrdersUserIdResponse
+func (c *ClientWithResponses) GetOrdersUserIdWithResponse(ctx context.Context, userId string, params *GetOrdersUserIdParams, reqEditors ...RequestEditorFn) (*GetOrdersUserIdResponse, error) {
+	rsp, err := c.GetOrdersUserId(ctx, userId, params, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return ParseGetOrdersUserIdResponse(rsp)
 }
 
-// ClientWithResponses builds on ClientInterface to offer response payloads
-type ClientWithResponses struct {
-	ClientInterface
+// GetOrdersUserIdEventsWithResponse request returning *GetOrdersUserIdEventsResponse
+func (c *ClientWithResponses) GetOrdersUserIdEventsWithResponse(ctx context.Context, userId string, params *GetOrdersUserIdEventsParams, reqEditors ...RequestEditorFn) (*GetOrdersUserIdEventsResponse, error) {
+	rsp, err := c.GetOrdersUserIdEvents(ctx, userId, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOrdersUserIdEventsResponse(rsp)
 }
 
-// NewClientWithResponses creates a new ClientWithResponses, which wraps
-// Client with return type handling
-func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
-	client, err := NewClient(server, opts...)
+// GetProductsWithResponse request returning *GetProductsResponse
+func (c *ClientWithResponses) GetProductsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetProductsResponse, error) {
+	rsp, err := c.GetProducts(ctx, reqEditors...)
 	if err != nil {
 		return nil, err
 	}
-	return &ClientWithResponses{client}, nil
+	return ParseGetProductsResponse(rsp)
 }
 
-// WithBaseURL overrides the baseURL.
-func WithBaseURL(baseURL string) ClientOption {
-	return func(c *Client) error {
-		newBaseURL, err := url.Parse(baseURL)
-		if err != nil {
-			return err
-		}
-		c.Server = newBaseURL.String()
-		return nil
+// GetStatusOrderIdWithResponse request returning *GetStatusOrderIdResponse
+func (c *ClientWithResponses) GetStatusOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetStatusOrderIdResponse, error) {
+	rsp, err := c.GetStatusOrderId(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
+	return ParseGetStatusOrderIdResponse(rsp)
 }
 
-// ClientWithResponsesInterface is the interface specification for the client with responses above.
-type ClientWithResponsesInterface interface {
-	// PostCreateUserIdWithBodyWithResponse request with any body
-	PostCreateUserIdWithBodyWithResponse(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error)
+// GetWebhooksUserIdWithResponse request returning *GetWebhooksUserIdResponse
+func (c *ClientWithResponses) GetWebhooksUserIdWithResponse(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*GetWebhooksUserIdResponse, error) {
+	rsp, err := c.GetWebhooksUserId(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetWebhooksUserIdResponse(rsp)
+}
 
-	PostCreateUserIdWithResponse(ctx context.Context, userId string, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error)
+// PostWebhooksUserIdWithBodyWithResponse request with arbitrary body returning *PostWebhooksUserIdResponse
+func (c *ClientWithResponses) PostWebhooksUserIdWithBodyWithResponse(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdResponse, error) {
+	rsp, err := c.PostWebhooksUserIdWithBody(ctx, userId, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostWebhooksUserIdResponse(rsp)
+}
 
-	// GetOrdersUserIdWithResponse request
-	GetOrdersUserIdWithResponse(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*GetOrdersUserIdResponse, error)
+func (c *ClientWithResponses) PostWebhooksUserIdWithResponse(ctx context.Context, userId string, body PostWebhooksUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdResponse, error) {
+	rsp, err := c.PostWebhooksUserId(ctx, userId, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostWebhooksUserIdResponse(rsp)
+}
 
-	// GetStatusOrderIdWithResponse request
-	GetStatusOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetStatusOrderIdResponse, error)
+// DeleteWebhooksUserIdEndpointIdWithResponse request returning *DeleteWebhooksUserIdEndpointIdResponse
+func (c *ClientWithResponses) DeleteWebhooksUserIdEndpointIdWithResponse(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*DeleteWebhooksUserIdEndpointIdResponse, error) {
+	rsp, err := c.DeleteWebhooksUserIdEndpointId(ctx, userId, endpointId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteWebhooksUserIdEndpointIdResponse(rsp)
 }
 
-type PostCreateUserIdResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON201      *Order
+// GetWebhooksUserIdEndpointIdDeliveriesWithResponse request returning *GetWebhooksUserIdEndpointIdDeliveriesResponse
+func (c *ClientWithResponses) GetWebhooksUserIdEndpointIdDeliveriesWithResponse(ctx context.Context, userId string, endpointId string, params *GetWebhooksUserIdEndpointIdDeliveriesParams, reqEditors ...RequestEditorFn) (*GetWebhooksUserIdEndpointIdDeliveriesResponse, error) {
+	rsp, err := c.GetWebhooksUserIdEndpointIdDeliveries(ctx, userId, endpointId, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetWebhooksUserIdEndpointIdDeliveriesResponse(rsp)
 }
 
-// Status returns HTTPResponse.Status
-func (r PostCreateUserIdResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverWithResponse request returning *PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse
+func (c *ClientWithResponses) PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverWithResponse(ctx context.Context, userId string, endpointId string, deliveryId int64, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse, error) {
+	rsp, err := c.PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(ctx, userId, endpointId, deliveryId, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+	return ParsePostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse(rsp)
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r PostCreateUserIdResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// PostWebhooksUserIdEndpointIdPingWithResponse request returning *PostWebhooksUserIdEndpointIdPingResponse
+func (c *ClientWithResponses) PostWebhooksUserIdEndpointIdPingWithResponse(ctx context.Context, userId string, endpointId string, reqEditors ...RequestEditorFn) (*PostWebhooksUserIdEndpointIdPingResponse, error) {
+	rsp, err := c.PostWebhooksUserIdEndpointIdPing(ctx, userId, endpointId, reqEditors...)
+	if err != nil {
+		return nil, err
 	}
-	return 0
+	return ParsePostWebhooksUserIdEndpointIdPingResponse(rsp)
 }
 
-type GetOrdersUserIdResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *[]Order
+// ParsePostCancelOrderIdResponse parses an HTTP response from a PostCancelOrderIdWithResponse call
+func ParsePostCancelOrderIdResponse(rsp *http.Response) (*PostCancelOrderIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostCancelOrderIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r GetOrdersUserIdResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParsePostCreateUserIdResponse parses an HTTP response from a PostCreateUserIdWithResponse call
+func ParsePostCreateUserIdResponse(rsp *http.Response) (*PostCreateUserIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+
+	response := &PostCreateUserIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 201:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+
+	}
+
+	return response, nil
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r GetOrdersUserIdResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// ParseGetOrdersOrderIdHistoryResponse parses an HTTP response from a GetOrdersOrderIdHistoryWithResponse call
+func ParseGetOrdersOrderIdHistoryResponse(rsp *http.Response) (*GetOrdersOrderIdHistoryResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return 0
+
+	response := &GetOrdersOrderIdHistoryResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []StatusChange
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-type GetStatusOrderIdResponse struct {
-	Body         []byte
-	HTTPResponse *http.Response
-	JSON200      *Order
+// ParseGetOrdersUserIdResponse parses an HTTP response from a GetOrdersUserIdWithResponse call
+func ParseGetOrdersUserIdResponse(rsp *http.Response) (*GetOrdersUserIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetOrdersUserIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest OrderPage
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// Status returns HTTPResponse.Status
-func (r GetStatusOrderIdResponse) Status() string {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.Status
+// ParseGetOrdersUserIdEventsResponse parses an HTTP response from a GetOrdersUserIdEventsWithResponse call
+func ParseGetOrdersUserIdEventsResponse(rsp *http.Response) (*GetOrdersUserIdEventsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return http.StatusText(0)
+
+	response := &GetOrdersUserIdEventsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
 }
 
-// StatusCode returns HTTPResponse.StatusCode
-func (r GetStatusOrderIdResponse) StatusCode() int {
-	if r.HTTPResponse != nil {
-		return r.HTTPResponse.StatusCode
+// ParseGetProductsResponse parses an HTTP response from a GetProductsWithResponse call
+func ParseGetProductsResponse(rsp *http.Response) (*GetProductsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
 	}
-	return 0
+
+	response := &GetProductsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []Product
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-// PostCreateUserIdWithBodyWithResponse request with arbitrary body returning *PostCreateUserIdResponse
-func (c *ClientWithResponses) PostCreateUserIdWithBodyWithResponse(ctx context.Context, userId string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error) {
-	rsp, err := c.PostCreateUserIdWithBody(ctx, userId, contentType, body, reqEditors...)
+// ParseGetStatusOrderIdResponse parses an HTTP response from a GetStatusOrderIdWithResponse call
+func ParseGetStatusOrderIdResponse(rsp *http.Response) (*GetStatusOrderIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParsePostCreateUserIdResponse(rsp)
+
+	response := &GetStatusOrderIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetWebhooksUserIdResponse parses an HTTP response from a GetWebhooksUserIdWithResponse call
+func ParseGetWebhooksUserIdResponse(rsp *http.Response) (*GetWebhooksUserIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetWebhooksUserIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []WebhookEndpoint
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
 }
 
-func (c *ClientWithResponses) PostCreateUserIdWithResponse(ctx context.Context, userId string, body PostCreateUserIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error) {
-	rsp, err := c.PostCreateUserId(ctx, userId, body, reqEditors...)
+// ParsePostWebhooksUserIdResponse parses an HTTP response from a PostWebhooksUserIdWithResponse call
+func ParsePostWebhooksUserIdResponse(rsp *http.Response) (*PostWebhooksUserIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParsePostCreateUserIdResponse(rsp)
-}
 
-// GetOrdersUserIdWithResponse request returning *GetOrdersUserIdResponse
-func (c *ClientWithResponses) GetOrdersUserIdWithResponse(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*GetOrdersUserIdResponse, error) {
-	rsp, err := c.GetOrdersUserId(ctx, userId, reqEditors...)
-	if err != nil {
-		return nil, err
+	response := &PostWebhooksUserIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
 	}
-	return ParseGetOrdersUserIdResponse(rsp)
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 201:
+		var dest WebhookEndpoint
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+
+	}
+
+	return response, nil
 }
 
-// GetStatusOrderIdWithResponse request returning *GetStatusOrderIdResponse
-func (c *ClientWithResponses) GetStatusOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetStatusOrderIdResponse, error) {
-	rsp, err := c.GetStatusOrderId(ctx, orderId, reqEditors...)
+// ParseDeleteWebhooksUserIdEndpointIdResponse parses an HTTP response from a DeleteWebhooksUserIdEndpointIdWithResponse call
+func ParseDeleteWebhooksUserIdEndpointIdResponse(rsp *http.Response) (*DeleteWebhooksUserIdEndpointIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
-	return ParseGetStatusOrderIdResponse(rsp)
+
+	response := &DeleteWebhooksUserIdEndpointIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
 }
 
-// ParsePostCreateUserIdResponse parses an HTTP response from a PostCreateUserIdWithResponse call
-func ParsePostCreateUserIdResponse(rsp *http.Response) (*PostCreateUserIdResponse, error) {
+// ParseGetWebhooksUserIdEndpointIdDeliveriesResponse parses an HTTP response from a GetWebhooksUserIdEndpointIdDeliveriesWithResponse call
+func ParseGetWebhooksUserIdEndpointIdDeliveriesResponse(rsp *http.Response) (*GetWebhooksUserIdEndpointIdDeliveriesResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &PostCreateUserIdResponse{
+	response := &GetWebhooksUserIdEndpointIdDeliveriesResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
-	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 201:
-		var dest Order
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []WebhookDelivery
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
-		response.JSON201 = &dest
+		response.JSON200 = &dest
 
 	}
 
 	return response, nil
 }
 
-// ParseGetOrdersUserIdResponse parses an HTTP response from a GetOrdersUserIdWithResponse call
-func ParseGetOrdersUserIdResponse(rsp *http.Response) (*GetOrdersUserIdResponse, error) {
+// ParsePostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse parses an HTTP response from a PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverWithResponse call
+func ParsePostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse(rsp *http.Response) (*PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &GetOrdersUserIdResponse{
+	response := &PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliverResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
-	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest []Order
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 202:
+		var dest WebhookDelivery
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
-		response.JSON200 = &dest
+		response.JSON202 = &dest
 
 	}
 
 	return response, nil
 }
 
-// ParseGetStatusOrderIdResponse parses an HTTP response from a GetStatusOrderIdWithResponse call
-func ParseGetStatusOrderIdResponse(rsp *http.Response) (*GetStatusOrderIdResponse, error) {
+// ParsePostWebhooksUserIdEndpointIdPingResponse parses an HTTP response from a PostWebhooksUserIdEndpointIdPingWithResponse call
+func ParsePostWebhooksUserIdEndpointIdPingResponse(rsp *http.Response) (*PostWebhooksUserIdEndpointIdPingResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &GetStatusOrderIdResponse{
+	response := &PostWebhooksUserIdEndpointIdPingResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
-	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest Order
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 202:
+		var dest WebhookDelivery
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
-		response.JSON200 = &dest
+		response.JSON202 = &dest
 
 	}
 
@@ -541,15 +2183,45 @@ func ParseGetStatusOrderIdResponse(rsp *http.Response) (*GetStatusOrderIdRespons
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// ╨б╨╛╨╖╨┤╨░╤В╤М ╨╖╨░╨║╨░╨╖
+	// Отменить заказ
+	// (POST /cancel/{order_id})
+	PostCancelOrderId(ctx echo.Context, orderId string) error
+	// Создать заказ
 	// (POST /create/{user_id})
-	PostCreateUserId(ctx echo.Context, userId string) error
-	// ╨Я╨╛╨╗╤Г╤З╨╕╤В╤М ╤Б╨┐╨╕╤Б╨╛╨║ ╨╖╨░╨║╨░╨╖╨╛╨▓ ╨┐╨╛╨╗╤М╨╖╨╛╨▓╨░╤В╨╡╨╗╤П
+	PostCreateUserId(ctx echo.Context, userId string, params PostCreateUserIdParams) error
+	// Получить историю статусов заказа
+	// (GET /orders/{order_id}/history)
+	GetOrdersOrderIdHistory(ctx echo.Context, orderId string) error
+	// Получить список заказов пользователя
 	// (GET /orders/{user_id})
-	GetOrdersUserId(ctx echo.Context, userId string) error
-	// ╨Я╨╛╨╗╤Г╤З╨╕╤В╤М ╤Б╤В╨░╤В╤Г╤Б ╨╖╨░╨║╨░╨╖╨░
+	GetOrdersUserId(ctx echo.Context, userId string, params GetOrdersUserIdParams) error
+	// Поток переходов статусов заказов пользователя
+	// (GET /orders/{user_id}/events)
+	GetOrdersUserIdEvents(ctx echo.Context, userId string, params GetOrdersUserIdEventsParams) error
+	// Получить каталог товаров
+	// (GET /products)
+	GetProducts(ctx echo.Context) error
+	// Получить статус заказа
 	// (GET /status/{order_id})
 	GetStatusOrderId(ctx echo.Context, orderId string) error
+	// Адреса вебхуков пользователя
+	// (GET /webhooks/{user_id})
+	GetWebhooksUserId(ctx echo.Context, userId string) error
+	// Зарегистрировать адрес вебхуков
+	// (POST /webhooks/{user_id})
+	PostWebhooksUserId(ctx echo.Context, userId string) error
+	// Удалить адрес вебхуков
+	// (DELETE /webhooks/{user_id}/{endpoint_id})
+	DeleteWebhooksUserIdEndpointId(ctx echo.Context, userId string, endpointId string) error
+	// Журнал доставок адреса
+	// (GET /webhooks/{user_id}/{endpoint_id}/deliveries)
+	GetWebhooksUserIdEndpointIdDeliveries(ctx echo.Context, userId string, endpointId string, params GetWebhooksUserIdEndpointIdDeliveriesParams) error
+	// Повторить доставку
+	// (POST /webhooks/{user_id}/{endpoint_id}/deliveries/{delivery_id}/redeliver)
+	PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(ctx echo.Context, userId string, endpointId string, deliveryId int64) error
+	// Отправить проверочное событие
+	// (POST /webhooks/{user_id}/{endpoint_id}/ping)
+	PostWebhooksUserIdEndpointIdPing(ctx echo.Context, userId string, endpointId string) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -557,6 +2229,22 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// PostCancelOrderId converts echo context to params.
+func (w *ServerInterfaceWrapper) PostCancelOrderId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "order_id" -------------
+	var orderId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_id", ctx.Param("order_id"), &orderId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter order_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostCancelOrderId(ctx, orderId)
+	return err
+}
+
 // PostCreateUserId converts echo context to params.
 func (w *ServerInterfaceWrapper) PostCreateUserId(ctx echo.Context) error {
 	var err error
@@ -568,8 +2256,44 @@ func (w *ServerInterfaceWrapper) PostCreateUserId(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostCreateUserIdParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostCreateUserId(ctx, userId, params)
+	return err
+}
+
+// GetOrdersOrderIdHistory converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOrdersOrderIdHistory(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "order_id" -------------
+	var orderId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_id", ctx.Param("order_id"), &orderId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter order_id: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PostCreateUserId(ctx, userId)
+	err = w.Handler.GetOrdersOrderIdHistory(ctx, orderId)
 	return err
 }
 
@@ -584,8 +2308,117 @@ func (w *ServerInterfaceWrapper) GetOrdersUserId(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetOrdersUserIdParams
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// ------------- Optional query parameter "created_from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_from", ctx.QueryParams(), &params.CreatedFrom)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter created_from: %s", err))
+	}
+
+	// ------------- Optional query parameter "created_to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_to", ctx.QueryParams(), &params.CreatedTo)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter created_to: %s", err))
+	}
+
+	// ------------- Optional query parameter "min_price" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "min_price", ctx.QueryParams(), &params.MinPrice)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter min_price: %s", err))
+	}
+
+	// ------------- Optional query parameter "max_price" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "max_price", ctx.QueryParams(), &params.MaxPrice)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter max_price: %s", err))
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", ctx.QueryParams(), &params.Sort)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter sort: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetOrdersUserId(ctx, userId, params)
+	return err
+}
+
+// GetOrdersUserIdEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOrdersUserIdEvents(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetOrdersUserIdEventsParams
+	// ------------- Optional query parameter "last_event_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "last_event_id", ctx.QueryParams(), &params.LastEventId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter last_event_id: %s", err))
+	}
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID int64
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Last-Event-ID, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Last-Event-ID: %s", err))
+		}
+
+		params.LastEventID = &LastEventID
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetOrdersUserId(ctx, userId)
+	err = w.Handler.GetOrdersUserIdEvents(ctx, userId, params)
+	return err
+}
+
+// GetProducts converts echo context to params.
+func (w *ServerInterfaceWrapper) GetProducts(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetProducts(ctx)
 	return err
 }
 
@@ -605,6 +2438,158 @@ func (w *ServerInterfaceWrapper) GetStatusOrderId(ctx echo.Context) error {
 	return err
 }
 
+// GetWebhooksUserId converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWebhooksUserId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWebhooksUserId(ctx, userId)
+	return err
+}
+
+// PostWebhooksUserId converts echo context to params.
+func (w *ServerInterfaceWrapper) PostWebhooksUserId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostWebhooksUserId(ctx, userId)
+	return err
+}
+
+// DeleteWebhooksUserIdEndpointId converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteWebhooksUserIdEndpointId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteWebhooksUserIdEndpointId(ctx, userId, endpointId)
+	return err
+}
+
+// GetWebhooksUserIdEndpointIdDeliveries converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWebhooksUserIdEndpointIdDeliveries(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetWebhooksUserIdEndpointIdDeliveriesParams
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", ctx.QueryParams(), &params.Status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter status: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWebhooksUserIdEndpointIdDeliveries(ctx, userId, endpointId, params)
+	return err
+}
+
+// PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver converts echo context to params.
+func (w *ServerInterfaceWrapper) PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	// ------------- Path parameter "delivery_id" -------------
+	var deliveryId int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "delivery_id", ctx.Param("delivery_id"), &deliveryId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter delivery_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(ctx, userId, endpointId, deliveryId)
+	return err
+}
+
+// PostWebhooksUserIdEndpointIdPing converts echo context to params.
+func (w *ServerInterfaceWrapper) PostWebhooksUserIdEndpointIdPing(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "user_id" -------------
+	var userId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "user_id", ctx.Param("user_id"), &userId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
+	}
+
+	// ------------- Path parameter "endpoint_id" -------------
+	var endpointId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "endpoint_id", ctx.Param("endpoint_id"), &endpointId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter endpoint_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PostWebhooksUserIdEndpointIdPing(ctx, userId, endpointId)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -633,8 +2618,18 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.POST(baseURL+"/cancel/:order_id", wrapper.PostCancelOrderId)
 	router.POST(baseURL+"/create/:user_id", wrapper.PostCreateUserId)
+	router.GET(baseURL+"/orders/:order_id/history", wrapper.GetOrdersOrderIdHistory)
 	router.GET(baseURL+"/orders/:user_id", wrapper.GetOrdersUserId)
+	router.GET(baseURL+"/orders/:user_id/events", wrapper.GetOrdersUserIdEvents)
+	router.GET(baseURL+"/products", wrapper.GetProducts)
 	router.GET(baseURL+"/status/:order_id", wrapper.GetStatusOrderId)
+	router.GET(baseURL+"/webhooks/:user_id", wrapper.GetWebhooksUserId)
+	router.POST(baseURL+"/webhooks/:user_id", wrapper.PostWebhooksUserId)
+	router.DELETE(baseURL+"/webhooks/:user_id/:endpoint_id", wrapper.DeleteWebhooksUserIdEndpointId)
+	router.GET(baseURL+"/webhooks/:user_id/:endpoint_id/deliveries", wrapper.GetWebhooksUserIdEndpointIdDeliveries)
+	router.POST(baseURL+"/webhooks/:user_id/:endpoint_id/deliveries/:delivery_id/redeliver", wrapper.PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver)
+	router.POST(baseURL+"/webhooks/:user_id/:endpoint_id/ping", wrapper.PostWebhooksUserIdEndpointIdPing)
 
 }
@@ -5,6 +5,8 @@ import (
 	"log"
 
 	services "sd_hw4/orders/internal/service"
+	"sd_hw4/pkg/config"
+	"sd_hw4/pkg/inbox"
 	"sd_hw4/pkg/messaging"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -12,25 +14,27 @@ import (
 
 type ConsumerHandler struct {
 	inboxService *services.InboxService
+	config       config.ConsumerConfig
 }
 
-func NewConsumerHandler(inboxService *services.InboxService) *ConsumerHandler {
+func NewConsumerHandler(inboxService *services.InboxService, consumerConfig config.ConsumerConfig) *ConsumerHandler {
 	return &ConsumerHandler{
 		inboxService: inboxService,
+		config:       consumerConfig,
 	}
 }
 
+// InboxQueue — очередь inbox, в которую сохраняются полученные сообщения
+func (h *ConsumerHandler) InboxQueue() string {
+	return h.config.Queue
+}
+
 // HandlePaymentResult обрабатывает сообщения о результате оплаты
 func (h *ConsumerHandler) HandlePaymentResult(ctx context.Context, delivery amqp.Delivery) error {
 	log.Printf("Received payment result message: %s", delivery.MessageId)
 
 	// Сохраняем сообщение в inbox
-	err := h.inboxService.SaveInboxMessage(
-		ctx,
-		delivery.MessageId,
-		"orders", // queue name для orders service
-		delivery.Body,
-	)
+	err := h.inboxService.SaveInboxMessage(ctx, inbox.FromDelivery(h.InboxQueue(), delivery))
 	if err != nil {
 		log.Printf("Failed to save inbox message: %v", err)
 		return err
@@ -42,17 +46,17 @@ func (h *ConsumerHandler) HandlePaymentResult(ctx context.Context, delivery amqp
 
 // StartConsumer запускает консьюмер для сообщений о результате оплаты
 func (h *ConsumerHandler) StartConsumer(ctx context.Context, conn *messaging.Connection) error {
-	config := messaging.ConsumerConfig{
-		QueueName:     "payments.payment_results",
-		ConsumerTag:   "orders-service",
+	consumerConfig := messaging.ConsumerConfig{
+		QueueName:     h.config.Queue,
+		ConsumerTag:   h.config.ConsumerTag,
 		AutoAck:       false,
 		Exclusive:     false,
 		NoLocal:       false,
 		NoWait:        false,
-		PrefetchCount: 10,
+		PrefetchCount: h.config.Prefetch,
 	}
 
-	consumer := messaging.NewConsumer(conn, config, h.HandlePaymentResult)
+	consumer := messaging.NewConsumer(conn, consumerConfig, h.HandlePaymentResult)
 
 	// Создаем очередь и биндинг
 	if err := h.setupQueue(conn); err != nil {
@@ -68,9 +72,9 @@ func (h *ConsumerHandler) setupQueue(conn *messaging.Connection) error {
 		return err
 	}
 
-	// Объявляем exchange для orders
+	// Объявляем exchange, в который payments публикует результаты оплаты
 	err = ch.ExchangeDeclare(
-		"orders",
+		h.config.Exchange,
 		"direct",
 		true,  // durable
 		false, // autoDelete
@@ -84,7 +88,7 @@ func (h *ConsumerHandler) setupQueue(conn *messaging.Connection) error {
 
 	// Объявляем очередь для результатов оплаты
 	_, err = ch.QueueDeclare(
-		"payments.payment_results",
+		h.config.Queue,
 		true,  // durable
 		false, // autoDelete
 		false, // exclusive
@@ -97,9 +101,9 @@ func (h *ConsumerHandler) setupQueue(conn *messaging.Connection) error {
 
 	// Биндим очередь к exchange
 	err = ch.QueueBind(
-		"payments.payment_results",
-		"payment.result",
-		"payments",
+		h.config.Queue,
+		h.config.RoutingKey,
+		h.config.Exchange,
 		false,
 		nil,
 	)
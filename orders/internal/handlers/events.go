@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	orders "sd_hw4/orders/internal/gen"
+	models "sd_hw4/orders/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// heartbeatInterval — интервал комментариев в потоке, чтобы прокси не закрывали простаивающее соединение
+	heartbeatInterval = 15 * time.Second
+	replayBatch       = 500
+)
+
+// StreamStatusEvents отправляет переходы статусов заказов пользователя как Server-Sent Events.
+// Если передан lastEventID, сначала отправляются переходы после него из истории статусов
+func (h *OrderHandler) StreamStatusEvents(c echo.Context, lastEventID int64) error {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+	if lastEventID < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Last-Event-ID")
+	}
+
+	// Подписка до чтения истории: переход, зафиксированный между ними, не потеряется,
+	// а повтор отбрасывается по id
+	events, unsubscribe := h.statusEvents.Subscribe(userID)
+	defer unsubscribe()
+
+	ctx := c.Request().Context()
+	var replayed []models.StatusEvent
+	for afterID := lastEventID; lastEventID > 0; {
+		batch, err := h.orderService.GetUserStatusEvents(ctx, userID, afterID, replayBatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get order history")
+		}
+		replayed = append(replayed, batch...)
+		if len(batch) < replayBatch {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	// nginx не должен буферизовать поток
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	sent := make(map[int64]bool, len(replayed))
+	for _, event := range replayed {
+		if err := writeStatusEvent(w, event); err != nil {
+			return nil
+		}
+		sent[event.ID] = true
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				// Сервер останавливается или клиент не успевает читать: клиент переподключится
+				return nil
+			}
+			if sent[event.ID] {
+				continue
+			}
+			if err := writeStatusEvent(w, event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		}
+	}
+}
+
+func writeStatusEvent(w *echo.Response, event models.StatusEvent) error {
+	data := orders.OrderStatusEvent{
+		Id:        event.ID,
+		OrderId:   event.OrderID.String(),
+		To:        orders.OrderStatus(event.To),
+		Reason:    event.Reason,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.From != "" {
+		from := orders.OrderStatus(event.From)
+		data.From = &from
+	}
+	if event.ReasonCode != "" {
+		code := orders.ReasonCode(event.ReasonCode)
+		data.ReasonCode = &code
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: status\ndata: %s\n\n", event.ID, payload); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
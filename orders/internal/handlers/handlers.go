@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
+	orders "sd_hw4/orders/internal/gen"
 	models "sd_hw4/orders/internal/models"
 	services "sd_hw4/orders/internal/service"
+	"sd_hw4/pkg/money"
+	"sd_hw4/pkg/webhooks"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -14,11 +20,18 @@ import (
 // OrderHandler обрабатывает HTTP запросы для заказов
 type OrderHandler struct {
 	orderService *services.OrderService
+	statusEvents *services.StatusEvents
+	webhooks     *webhooks.Store
+	// allowPrivateWebhooks разрешает регистрировать адреса вебхуков во внутренней сети
+	allowPrivateWebhooks bool
 }
 
-func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
+func NewOrderHandler(orderService *services.OrderService, statusEvents *services.StatusEvents, webhookStore *webhooks.Store, allowPrivateWebhooks bool) *OrderHandler {
 	return &OrderHandler{
-		orderService: orderService,
+		orderService:         orderService,
+		statusEvents:         statusEvents,
+		webhooks:             webhookStore,
+		allowPrivateWebhooks: allowPrivateWebhooks,
 	}
 }
 
@@ -30,60 +43,101 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
 	}
 
-	var req struct {
-		Amount      float64 `json:"amount"`
-		Description string  `json:"description"`
-	}
-
+	var req orders.CreateOrderRequest
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
 
-	if req.Amount <= 0 {
-		return echo.NewHTTPError(http.StatusBadRequest, "Amount must be positive")
+	items := make([]models.ItemRequest, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.ItemRequest{SKU: item.Sku, Quantity: item.Quantity}
+	}
+	var description string
+	if req.Description != nil {
+		description = *req.Description
 	}
 
-	// Используем сервис для создания заказа
-	order, err := h.orderService.CreateOrder(c.Request().Context(), userID, req.Amount, req.Description)
+	// Используем сервис для создания заказа; сумма считается по каталогу
+	order, err := h.orderService.CreateOrder(c.Request().Context(), userID, items, description)
+	if errors.Is(err, services.ErrInvalidOrder) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create order: %v", err))
 	}
 
-	// Преобразуем в DTO для ответа
-	response := models.Order{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		Price:       order.Price,
-		Description: order.Description,
-		Status:      string(models.OrderStatus(order.Status)),
+	return c.JSON(http.StatusCreated, toOrderResponse(*order))
+}
+
+// ListProducts возвращает каталог товаров
+func (h *OrderHandler) ListProducts(c echo.Context) error {
+	products, err := h.orderService.ListProducts(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get products")
+	}
+
+	response := make([]orders.Product, len(products))
+	for i, product := range products {
+		response[i] = orders.Product{
+			Id:    product.ID.String(),
+			Sku:   product.SKU,
+			Name:  product.Name,
+			Price: product.Price,
+		}
 	}
 
-	return c.JSON(http.StatusCreated, response)
+	return c.JSON(http.StatusOK, response)
 }
 
-// GetUserOrders возвращает заказы пользователя
-func (h *OrderHandler) GetUserOrders(c echo.Context) error {
+// GetUserOrders возвращает страницу заказов пользователя с фильтрами из params
+func (h *OrderHandler) GetUserOrders(c echo.Context, params orders.GetOrdersUserIdParams) error {
 	userIDParam := c.Param("user_id")
 	userID, err := uuid.Parse(userIDParam)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
 	}
 
-	orders, err := h.orderService.GetOrdersByUser(c.Request().Context(), userID)
+	filter := models.OrderFilter{
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+	}
+	if filter.MinPrice, err = parsePrice("min_price", params.MinPrice); err != nil {
+		return err
+	}
+	if filter.MaxPrice, err = parsePrice("max_price", params.MaxPrice); err != nil {
+		return err
+	}
+	if params.Status != nil {
+		for _, status := range *params.Status {
+			filter.Statuses = append(filter.Statuses, models.OrderStatus(status))
+		}
+	}
+	if params.Sort != nil {
+		filter.Sort = models.OrderSort(*params.Sort)
+	}
+	if params.Limit != nil {
+		filter.Limit = *params.Limit
+	}
+	var cursor string
+	if params.Cursor != nil {
+		cursor = *params.Cursor
+	}
+
+	page, err := h.orderService.GetOrdersByUser(c.Request().Context(), userID, filter, cursor)
+	if errors.Is(err, services.ErrInvalidQuery) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get orders")
 	}
 
 	// Преобразуем в DTO для ответа
-	response := make([]models.Order, len(orders))
-	for i, order := range orders {
-		response[i] = models.Order{
-			ID:          order.ID,
-			UserID:      order.UserID,
-			Price:       order.Price,
-			Description: order.Description,
-			Status:      string(models.OrderStatus(order.Status)),
-		}
+	response := orders.OrderPage{Orders: make([]orders.Order, len(page.Orders))}
+	for i, order := range page.Orders {
+		response.Orders[i] = toOrderResponse(order)
+	}
+	if page.NextCursor != "" {
+		response.NextCursor = &page.NextCursor
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -98,34 +152,162 @@ func (h *OrderHandler) GetOrderStatus(c echo.Context) error {
 	}
 
 	order, err := h.orderService.GetOrderByID(c.Request().Context(), orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get order")
+	}
+
+	return c.JSON(http.StatusOK, toOrderResponse(*order))
+}
+
+// CancelOrder отменяет заказ или запрашивает возврат оплаты
+func (h *OrderHandler) CancelOrder(c echo.Context) error {
+	orderID, err := uuid.Parse(c.Param("order_id"))
 	if err != nil {
-		// Здесь можно проверить тип ошибки (NotFound vs другие)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	order, err := h.orderService.CancelOrder(c.Request().Context(), orderID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
 		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	case errors.Is(err, services.ErrIllegalTransition):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to cancel order")
 	}
 
-	// Преобразуем в DTO для ответа
-	response := models.Order{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		Price:       order.Price,
-		Description: order.Description,
-		Status:      string(models.OrderStatus(order.Status)),
+	return c.JSON(http.StatusOK, toOrderResponse(*order))
+}
+
+// GetOrderHistory возвращает историю статусов заказа
+func (h *OrderHandler) GetOrderHistory(c echo.Context) error {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid order ID")
+	}
+
+	history, err := h.orderService.GetStatusHistory(c.Request().Context(), orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Order not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get order history")
+	}
+
+	response := make([]orders.StatusChange, len(history))
+	for i, change := range history {
+		response[i] = orders.StatusChange{
+			To:        orders.OrderStatus(change.To),
+			Reason:    change.Reason,
+			CreatedAt: change.CreatedAt,
+		}
+		if change.From != "" {
+			from := orders.OrderStatus(change.From)
+			response[i].From = &from
+		}
+		if change.ReasonCode != "" {
+			code := orders.ReasonCode(change.ReasonCode)
+			response[i].ReasonCode = &code
+		}
+		if change.MessageID != "" {
+			messageID := change.MessageID
+			response[i].MessageId = &messageID
+		}
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// parsePrice разбирает границу суммы заказа из параметра name; суммы каталога и заказов — в RUB
+func parsePrice(name string, value *string) (*money.Money, error) {
+	if value == nil {
+		return nil, nil
+	}
+	price, err := money.Parse(*value, money.DefaultCurrency)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid %s: %v", name, err))
+	}
+	return &price, nil
+}
+
+// toOrderResponse преобразует заказ в DTO для ответа
+func toOrderResponse(order models.Order) orders.Order {
+	items := make([]orders.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		// Стоимость позиций сохраненного заказа проверена при создании (см. fillItems)
+		amount, _ := item.Amount()
+		items[i] = orders.OrderItem{
+			ProductId: item.ProductID.String(),
+			Sku:       item.SKU,
+			Name:      item.Name,
+			UnitPrice: item.UnitPrice,
+			Quantity:  item.Quantity,
+			Amount:    amount,
+		}
+	}
+
+	response := orders.Order{
+		Id:          order.ID.String(),
+		UserId:      order.UserID.String(),
+		Amount:      order.Price,
+		Description: order.Description,
+		Status:      orders.OrderStatus(order.Status),
+		Items:       items,
+		CreatedAt:   order.CreatedAt,
+	}
+	if order.CancelReason != nil {
+		response.CancelReason = &orders.CancelReason{
+			Code:    orders.ReasonCode(order.CancelReason.Code),
+			Message: order.CancelReason.Message,
+		}
+	}
+	return response
+}
+
 // RegisterRoutes регистрирует маршруты (реализация ServerInterface)
-func (h *OrderHandler) PostCreateUserId(c echo.Context, userId string) error {
+func (h *OrderHandler) PostCreateUserId(c echo.Context, userId string, _ orders.PostCreateUserIdParams) error {
 	c.SetParamNames("user_id")
 	c.SetParamValues(userId)
 	return h.CreateOrder(c)
 }
 
-func (h *OrderHandler) GetOrdersUserId(c echo.Context, userId string) error {
+func (h *OrderHandler) GetOrdersUserId(c echo.Context, userId string, params orders.GetOrdersUserIdParams) error {
+	c.SetParamNames("user_id")
+	c.SetParamValues(userId)
+	return h.GetUserOrders(c, params)
+}
+
+func (h *OrderHandler) GetOrdersUserIdEvents(c echo.Context, userId string, params orders.GetOrdersUserIdEventsParams) error {
 	c.SetParamNames("user_id")
 	c.SetParamValues(userId)
-	return h.GetUserOrders(c)
+
+	var lastEventID int64
+	switch {
+	case params.LastEventID != nil:
+		lastEventID = *params.LastEventID
+	case params.LastEventId != nil:
+		lastEventID = *params.LastEventId
+	}
+	return h.StreamStatusEvents(c, lastEventID)
+}
+
+func (h *OrderHandler) PostCancelOrderId(c echo.Context, orderId string) error {
+	c.SetParamNames("order_id")
+	c.SetParamValues(orderId)
+	return h.CancelOrder(c)
+}
+
+func (h *OrderHandler) GetProducts(c echo.Context) error {
+	return h.ListProducts(c)
+}
+
+func (h *OrderHandler) GetOrdersOrderIdHistory(c echo.Context, orderId string) error {
+	c.SetParamNames("order_id")
+	c.SetParamValues(orderId)
+	return h.GetOrderHistory(c)
 }
 
 func (h *OrderHandler) GetStatusOrderId(c echo.Context, orderId string) error {
@@ -133,3 +315,39 @@ func (h *OrderHandler) GetStatusOrderId(c echo.Context, orderId string) error {
 	c.SetParamValues(orderId)
 	return h.GetOrderStatus(c)
 }
+
+func (h *OrderHandler) GetWebhooksUserId(c echo.Context, userId string) error {
+	c.SetParamNames("user_id")
+	c.SetParamValues(userId)
+	return h.ListWebhookEndpoints(c)
+}
+
+func (h *OrderHandler) PostWebhooksUserId(c echo.Context, userId string) error {
+	c.SetParamNames("user_id")
+	c.SetParamValues(userId)
+	return h.CreateWebhookEndpoint(c)
+}
+
+func (h *OrderHandler) DeleteWebhooksUserIdEndpointId(c echo.Context, userId string, endpointId string) error {
+	c.SetParamNames("user_id", "endpoint_id")
+	c.SetParamValues(userId, endpointId)
+	return h.DeleteWebhookEndpoint(c)
+}
+
+func (h *OrderHandler) PostWebhooksUserIdEndpointIdPing(c echo.Context, userId string, endpointId string) error {
+	c.SetParamNames("user_id", "endpoint_id")
+	c.SetParamValues(userId, endpointId)
+	return h.PingWebhookEndpoint(c)
+}
+
+func (h *OrderHandler) GetWebhooksUserIdEndpointIdDeliveries(c echo.Context, userId string, endpointId string, params orders.GetWebhooksUserIdEndpointIdDeliveriesParams) error {
+	c.SetParamNames("user_id", "endpoint_id")
+	c.SetParamValues(userId, endpointId)
+	return h.ListWebhookDeliveries(c, params)
+}
+
+func (h *OrderHandler) PostWebhooksUserIdEndpointIdDeliveriesDeliveryIdRedeliver(c echo.Context, userId string, endpointId string, deliveryId int64) error {
+	c.SetParamNames("user_id", "endpoint_id", "delivery_id")
+	c.SetParamValues(userId, endpointId, strconv.FormatInt(deliveryId, 10))
+	return h.RedeliverWebhook(c)
+}
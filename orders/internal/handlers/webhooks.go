@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	orders "sd_hw4/orders/internal/gen"
+	"sd_hw4/pkg/contracts"
+	"sd_hw4/pkg/webhooks"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxWebhookEndpoints = 10
+	minSecretLength     = 16
+	maxSecretLength     = 200
+	defaultDeliveries   = 20
+	maxDeliveries       = 100
+)
+
+// webhookEventTypes — события, на которые можно подписать адрес
+var webhookEventTypes = map[orders.WebhookEventType]bool{
+	contracts.TypeOrderCreated:  true,
+	contracts.TypeOrderPaid:     true,
+	contracts.TypeOrderCanceled: true,
+	contracts.TypeOrderRefunded: true,
+}
+
+// CreateWebhookEndpoint регистрирует адрес вебхуков пользователя; секрет возвращается только здесь
+func (h *OrderHandler) CreateWebhookEndpoint(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req orders.WebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	// Адреса внутренней сети запрещены: иначе через вебхуки можно обращаться к сервисам изнутри
+	target, err := webhooks.ValidateURL(c.Request().Context(), req.Url, h.allowPrivateWebhooks)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	endpoint := &webhooks.Endpoint{OwnerID: userID.String(), URL: target.String(), Events: []string{}}
+	if req.Events != nil {
+		seen := make(map[orders.WebhookEventType]bool)
+		for _, eventType := range *req.Events {
+			if !webhookEventTypes[eventType] {
+				return echo.NewHTTPError(http.StatusBadRequest, "Unknown event type "+string(eventType))
+			}
+			if !seen[eventType] {
+				seen[eventType] = true
+				endpoint.Events = append(endpoint.Events, string(eventType))
+			}
+		}
+	}
+
+	if req.Secret != nil {
+		if len(*req.Secret) < minSecretLength || len(*req.Secret) > maxSecretLength {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"secret must be "+strconv.Itoa(minSecretLength)+" to "+strconv.Itoa(maxSecretLength)+" characters long")
+		}
+		endpoint.Secret = *req.Secret
+	} else if endpoint.Secret, err = generateSecret(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate secret")
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.webhooks.ListEndpoints(ctx, endpoint.OwnerID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to register webhook endpoint")
+	}
+	if len(existing) >= maxWebhookEndpoints {
+		return echo.NewHTTPError(http.StatusBadRequest, "At most "+strconv.Itoa(maxWebhookEndpoints)+" webhook endpoints per user are allowed")
+	}
+
+	if err := h.webhooks.CreateEndpoint(ctx, endpoint); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to register webhook endpoint")
+	}
+
+	response := toWebhookEndpointResponse(*endpoint)
+	response.Secret = &endpoint.Secret
+	return c.JSON(http.StatusCreated, response)
+}
+
+// ListWebhookEndpoints возвращает адреса вебхуков пользователя без секретов
+func (h *OrderHandler) ListWebhookEndpoints(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	endpoints, err := h.webhooks.ListEndpoints(c.Request().Context(), userID.String())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get webhook endpoints")
+	}
+
+	response := make([]orders.WebhookEndpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		response[i] = toWebhookEndpointResponse(endpoint)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhookEndpoint удаляет адрес вебхуков вместе с журналом доставок
+func (h *OrderHandler) DeleteWebhookEndpoint(c echo.Context) error {
+	userID, endpointID, err := webhookEndpointParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.webhooks.DeleteEndpoint(c.Request().Context(), userID.String(), endpointID)
+	if errors.Is(err, webhooks.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhook endpoint not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete webhook endpoint")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PingWebhookEndpoint ставит доставку проверочного события на адрес
+func (h *OrderHandler) PingWebhookEndpoint(c echo.Context) error {
+	endpoint, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]string{"endpoint_id": endpoint.ID.String()})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to send ping")
+	}
+	event := webhooks.Event{ID: uuid.New().String(), Type: webhooks.EventPing, CreatedAt: time.Now(), Data: data}
+
+	delivery, err := h.webhooks.EnqueueTo(c.Request().Context(), endpoint.ID, event)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to send ping")
+	}
+	return c.JSON(http.StatusAccepted, toWebhookDeliveryResponse(*delivery))
+}
+
+// ListWebhookDeliveries возвращает журнал доставок адреса, начиная с самых новых
+func (h *OrderHandler) ListWebhookDeliveries(c echo.Context, params orders.GetWebhooksUserIdEndpointIdDeliveriesParams) error {
+	endpoint, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+
+	limit := defaultDeliveries
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+	if limit < 1 || limit > maxDeliveries {
+		return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxDeliveries))
+	}
+	var status webhooks.Status
+	if params.Status != nil {
+		status = webhooks.Status(*params.Status)
+		switch status {
+		case webhooks.StatusPending, webhooks.StatusFailed, webhooks.StatusDelivered, webhooks.StatusDead:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Unknown delivery status "+string(status))
+		}
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(c.Request().Context(), endpoint.ID, status, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get webhook deliveries")
+	}
+
+	response := make([]orders.WebhookDelivery, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = toWebhookDeliveryResponse(delivery)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// RedeliverWebhook снова ставит доставку в очередь с полным запасом попыток
+func (h *OrderHandler) RedeliverWebhook(c echo.Context) error {
+	endpoint, err := h.webhookEndpoint(c)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid delivery ID")
+	}
+
+	delivery, err := h.webhooks.Redeliver(c.Request().Context(), endpoint.ID, deliveryID)
+	switch {
+	case errors.Is(err, webhooks.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Webhook delivery not found")
+	case errors.Is(err, webhooks.ErrInvalidState):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to redeliver webhook")
+	}
+	return c.JSON(http.StatusAccepted, toWebhookDeliveryResponse(*delivery))
+}
+
+// webhookEndpoint возвращает адрес из пути запроса, если он принадлежит пользователю из пути
+func (h *OrderHandler) webhookEndpoint(c echo.Context) (*webhooks.Endpoint, error) {
+	userID, endpointID, err := webhookEndpointParams(c)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := h.webhooks.GetEndpoint(c.Request().Context(), userID.String(), endpointID)
+	if errors.Is(err, webhooks.ErrNotFound) {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Webhook endpoint not found")
+	}
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to get webhook endpoint")
+	}
+	return endpoint, nil
+}
+
+func webhookEndpointParams(c echo.Context) (uuid.UUID, uuid.UUID, error) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	}
+	endpointID, err := uuid.Parse(c.Param("endpoint_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook endpoint ID")
+	}
+	return userID, endpointID, nil
+}
+
+// generateSecret возвращает случайный секрет подписи
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// toWebhookEndpointResponse преобразует адрес в DTO для ответа без секрета
+func toWebhookEndpointResponse(endpoint webhooks.Endpoint) orders.WebhookEndpoint {
+	events := make([]orders.WebhookEventType, len(endpoint.Events))
+	for i, eventType := range endpoint.Events {
+		events[i] = orders.WebhookEventType(eventType)
+	}
+
+	return orders.WebhookEndpoint{
+		Id:        endpoint.ID.String(),
+		Url:       endpoint.URL,
+		Events:    events,
+		CreatedAt: endpoint.CreatedAt,
+	}
+}
+
+// toWebhookDeliveryResponse преобразует доставку в DTO для ответа
+func toWebhookDeliveryResponse(delivery webhooks.Delivery) orders.WebhookDelivery {
+	var event orders.WebhookEvent
+	if err := json.Unmarshal(delivery.Payload, &event); err != nil {
+		// Тело записано сервисом и всегда разбирается; на всякий случай показываем хотя бы тип
+		event = orders.WebhookEvent{Id: delivery.EventID, Type: delivery.EventType, Data: map[string]interface{}{}}
+	}
+
+	return orders.WebhookDelivery{
+		Id:             delivery.ID,
+		EndpointId:     delivery.EndpointID.String(),
+		Event:          event,
+		Status:         orders.WebhookDeliveryStatus(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
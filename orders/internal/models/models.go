@@ -1,71 +1,173 @@
 package domain
 
 import (
-	"encoding/json"
+	"slices"
 	"time"
 
+	"sd_hw4/pkg/contracts"
+	"sd_hw4/pkg/money"
+
 	"github.com/google/uuid"
 )
 
 type OrderStatus string
 
 const (
-	OrderStatusNew      OrderStatus = "new"
+	// OrderStatusNew — заказ создан; в той же транзакции он переходит в payment_pending
+	OrderStatusNew OrderStatus = "new"
+	// OrderStatusPaymentPending — запрос на оплату отправлен, результат еще не получен
+	OrderStatusPaymentPending OrderStatus = "payment_pending"
+	// OrderStatusFinished — заказ оплачен
 	OrderStatusFinished OrderStatus = "finished"
 	OrderStatusCanceled OrderStatus = "canceled"
+	// OrderStatusRefundPending — оплаченный заказ отменен, запрос на возврат оплаты отправлен
+	OrderStatusRefundPending OrderStatus = "refund_pending"
+	// OrderStatusRefunded — оплата заказа возвращена
+	OrderStatusRefunded OrderStatus = "refunded"
 )
 
-type Order struct {
-	ID          uuid.UUID
-	UserID      uuid.UUID
-	Price       float64
-	Description string
-	Status      string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+// orderStatuses — все статусы заказа
+var orderStatuses = []OrderStatus{
+	OrderStatusNew,
+	OrderStatusPaymentPending,
+	OrderStatusFinished,
+	OrderStatusCanceled,
+	OrderStatusRefundPending,
+	OrderStatusRefunded,
+}
+
+// Valid сообщает, известен ли статус s
+func (s OrderStatus) Valid() bool {
+	return slices.Contains(orderStatuses, s)
+}
+
+// orderTransitions — допустимые переходы между статусами заказа.
+// canceled и refunded — конечные статусы
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusNew:            {OrderStatusPaymentPending, OrderStatusCanceled},
+	OrderStatusPaymentPending: {OrderStatusFinished, OrderStatusCanceled},
+	OrderStatusFinished:       {OrderStatusRefundPending},
+	OrderStatusRefundPending:  {OrderStatusRefunded},
 }
 
-type PaymentRequest struct {
-	OrderID     uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	Price       float64   `json:"price"`
-	Description string    `json:"description"`
+// CanTransitionTo сообщает, разрешен ли переход из статуса s в next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], next)
 }
 
-type PaymentResult struct {
+// StatusChange — запись истории статусов заказа
+type StatusChange struct {
+	ID      int64
 	OrderID uuid.UUID
-	Success bool
-	Reason  string
+	// From пуст для записи о создании заказа
+	From   OrderStatus
+	To     OrderStatus
+	Reason string
+	// ReasonCode заполняется у перехода, который отменяет заказ (canceled, refund_pending)
+	ReasonCode contracts.ReasonCode
+	// MessageID — идентификатор сообщения, которое вызвало переход (результат оплаты
+	// из inbox или запрос на оплату в outbox); пуст для переходов по запросу клиента
+	MessageID string
+	CreatedAt time.Time
 }
 
-type OutboxMessage struct {
-	ID         uuid.UUID       `db:"id"`
-	MessageID  string          `db:"message_id"`
-	Exchange   string          `db:"exchange"`
-	RoutingKey string          `db:"routing_key"`
-	Payload    json.RawMessage `db:"payload"`
-	Headers    json.RawMessage `db:"headers"`
-	Status     OutboxStatus    `db:"status"`
-	CreatedAt  time.Time       `db:"created_at"`
-	SentAt     *time.Time      `db:"sent_at"`
-	Error      *string         `db:"error"`
-	RetryCount int             `db:"retry_count"`
+// StatusEvent — переход статуса заказа в потоке событий пользователя; ID перехода
+// служит идентификатором события для возобновления потока
+type StatusEvent struct {
+	StatusChange
+	UserID uuid.UUID
 }
 
-type OutboxStatus string
+type Order struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Price — сумма позиций заказа в валюте каталога, считается сервисом
+	Price       money.Money
+	Description string
+	Status      string
+	// CancelReason — почему заказ отменен; nil, если заказ не отменялся
+	CancelReason *CancelReason
+	Items        []OrderItem
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// CancelReason — причина отмены заказа: стандартный код и текст для пользователя
+type CancelReason struct {
+	Code    contracts.ReasonCode
+	Message string
+}
+
+// OrderSort — порядок списка заказов; при равных значениях заказы упорядочиваются по ID
+type OrderSort string
 
 const (
-	StatusPending OutboxStatus = "pending"
-	StatusSent    OutboxStatus = "sent"
-	StatusFailed  OutboxStatus = "failed"
+	// OrderSortCreatedDesc — сначала новые (по умолчанию)
+	OrderSortCreatedDesc OrderSort = "created_at_desc"
+	OrderSortCreatedAsc  OrderSort = "created_at_asc"
+	OrderSortPriceDesc   OrderSort = "price_desc"
+	OrderSortPriceAsc    OrderSort = "price_asc"
 )
 
-type InboxMessage struct {
-	ID          uuid.UUID       `db:"id"`
-	MessageID   string          `db:"message_id"`
-	Queue       string          `db:"queue"`
-	Payload     json.RawMessage `db:"payload"`
-	Processed   bool            `db:"processed"`
-	ProcessedAt *time.Time      `db:"processed_at"`
-	CreatedAt   time.Time       `db:"created_at"`
+// Valid сообщает, известен ли порядок s
+func (s OrderSort) Valid() bool {
+	switch s {
+	case OrderSortCreatedDesc, OrderSortCreatedAsc, OrderSortPriceDesc, OrderSortPriceAsc:
+		return true
+	}
+	return false
+}
+
+// OrderFilter — условия выборки заказов пользователя; пустые поля не ограничивают выборку
+type OrderFilter struct {
+	Statuses []OrderStatus
+	// CreatedFrom включается в диапазон, CreatedTo — нет
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinPrice    *money.Money
+	MaxPrice    *money.Money
+	Sort        OrderSort
+	Limit       int
+	// After — последний заказ предыдущей страницы; выборка продолжается после него в порядке Sort
+	After *OrderCursor
+}
+
+// OrderCursor — позиция заказа в списке: значения, по которым упорядочен список
+type OrderCursor struct {
+	CreatedAt time.Time
+	Price     money.Money
+	ID        uuid.UUID
+}
+
+// Product — товар каталога
+type Product struct {
+	ID        uuid.UUID
+	SKU       string
+	Name      string
+	Price     money.Money
+	Active    bool
+	CreatedAt time.Time
+}
+
+// OrderItem — позиция заказа; название и цена зафиксированы на момент заказа
+type OrderItem struct {
+	ID        uuid.UUID
+	OrderID   uuid.UUID
+	ProductID uuid.UUID
+	SKU       string
+	Name      string
+	// UnitPrice — в валюте заказа
+	UnitPrice money.Money
+	Quantity  int
+}
+
+// Amount возвращает стоимость позиции: цену, умноженную на количество
+func (i OrderItem) Amount() (money.Money, error) {
+	return i.UnitPrice.Mul(int64(i.Quantity))
+}
+
+// ItemRequest — позиция, которую клиент добавляет в заказ
+type ItemRequest struct {
+	SKU      string
+	Quantity int
 }
@@ -0,0 +1,49 @@
+package domain
+
+import "testing"
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	allowed := map[OrderStatus][]OrderStatus{
+		OrderStatusNew:            {OrderStatusPaymentPending, OrderStatusCanceled},
+		OrderStatusPaymentPending: {OrderStatusFinished, OrderStatusCanceled},
+		OrderStatusFinished:       {OrderStatusRefundPending},
+		OrderStatusRefundPending:  {OrderStatusRefunded},
+		OrderStatusCanceled:       nil,
+		OrderStatusRefunded:       nil,
+	}
+
+	for _, from := range orderStatuses {
+		for _, to := range orderStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: CanTransitionTo = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestOrderStatusCanTransitionToUnknown(t *testing.T) {
+	unknown := OrderStatus("shipped")
+	for _, status := range orderStatuses {
+		if status.CanTransitionTo(unknown) {
+			t.Errorf("%s -> %s must not be allowed", status, unknown)
+		}
+		if unknown.CanTransitionTo(status) {
+			t.Errorf("%s -> %s must not be allowed", unknown, status)
+		}
+	}
+}
+
+func TestOrderStatusValid(t *testing.T) {
+	for _, status := range orderStatuses {
+		if !status.Valid() {
+			t.Errorf("%s: Valid = false, want true", status)
+		}
+	}
+	if OrderStatus("shipped").Valid() {
+		t.Error("shipped: Valid = true, want false")
+	}
+}
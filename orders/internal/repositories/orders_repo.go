@@ -3,12 +3,17 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	models "sd_hw4/orders/internal/models"
+	"sd_hw4/pkg/contracts"
 	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/money"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type OrderRepository struct {
@@ -19,107 +24,401 @@ func NewOrderRepository(db *sql.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// Create создает новый заказ
-func (r *OrderRepository) Create(ctx context.Context, order *models.Order) error {
+// Create создает новый заказ вместе с позициями; exec позволяет выполнить вставку внутри транзакции
+func (r *OrderRepository) Create(ctx context.Context, exec db.Executor, order *models.Order) error {
 	order.ID = uuid.New()
 	order.CreatedAt = time.Now()
 
 	query := `
-        INSERT INTO orders (id, user_id, price, description, status, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO orders (id, user_id, price, currency, description, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     `
 
-	_, err := db.Exec(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		order.ID,
 		order.UserID,
-		order.Price,
+		order.Price.Decimal(),
+		order.Price.Currency(),
 		order.Description,
 		order.Status,
 		order.CreatedAt,
 		order.UpdatedAt,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	itemQuery := `
+        INSERT INTO order_items (id, order_id, product_id, sku, name, unit_price, quantity)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.ID = uuid.New()
+		item.OrderID = order.ID
+		_, err := exec.ExecContext(ctx, itemQuery,
+			item.ID,
+			item.OrderID,
+			item.ProductID,
+			item.SKU,
+			item.Name,
+			item.UnitPrice.Decimal(),
+			item.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // GetByID возвращает заказ по ID
 func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
 	query := `
-        SELECT id, user_id, price, description, status, created_at, updated_at
+        SELECT id, user_id, price, currency, description, status, cancel_reason_code, cancel_reason, created_at, updated_at
         FROM orders
         WHERE id = $1
     `
 
+	order, err := scanOrder(db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := r.withItems(ctx, []models.Order{*order})
+	if err != nil {
+		return nil, err
+	}
+
+	return &orders[0], nil
+}
+
+// orderSortKeys — столбец и направление для каждого порядка сортировки; id разрешает равенства
+var orderSortKeys = map[models.OrderSort]struct {
+	column string
+	desc   bool
+}{
+	models.OrderSortCreatedDesc: {"created_at", true},
+	models.OrderSortCreatedAsc:  {"created_at", false},
+	models.OrderSortPriceDesc:   {"price", true},
+	models.OrderSortPriceAsc:    {"price", false},
+}
+
+// ListByUser возвращает до filter.Limit заказов пользователя, подходящих под filter.
+// Страницы выбираются по ключу (столбец сортировки, id) после filter.After, а не через OFFSET,
+// поэтому новые заказы не сдвигают уже просмотренные страницы
+func (r *OrderRepository) ListByUser(ctx context.Context, userID uuid.UUID, filter models.OrderFilter) ([]models.Order, error) {
+	key, ok := orderSortKeys[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown order sort %q", filter.Sort)
+	}
+
+	args := []any{userID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"user_id = $1"}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= "+arg(filter.MinPrice.Decimal()))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= "+arg(filter.MaxPrice.Decimal()))
+	}
+
+	direction, compare := "ASC", ">"
+	if key.desc {
+		direction, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		var value any = filter.After.CreatedAt
+		if key.column == "price" {
+			value = filter.After.Price.Decimal()
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", key.column, compare, arg(value), arg(filter.After.ID)))
+	}
+
+	query := `
+        SELECT id, user_id, price, currency, description, status, cancel_reason_code, cancel_reason, created_at, updated_at
+        FROM orders
+        WHERE ` + strings.Join(conditions, " AND ") + `
+        ORDER BY ` + key.column + ` ` + direction + `, id ` + direction + `
+        LIMIT ` + arg(filter.Limit)
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.withItems(ctx, orders)
+}
+
+// scanOrder читает заказ без позиций из строки выборки столбцов id, user_id, price, currency,
+// description, status, cancel_reason_code, cancel_reason, created_at, updated_at
+func scanOrder(row interface{ Scan(...any) error }) (*models.Order, error) {
 	var order models.Order
-	err := db.QueryRow(ctx, query, id).Scan(
+	var price, currency string
+	var cancelCode, cancelMessage sql.NullString
+	err := row.Scan(
 		&order.ID,
 		&order.UserID,
-		&order.Price,
+		&price,
+		&currency,
 		&order.Description,
 		&order.Status,
+		&cancelCode,
+		&cancelMessage,
 		&order.CreatedAt,
 		&order.UpdatedAt,
 	)
-
 	if err != nil {
 		return nil, err
 	}
-
+	if cancelCode.Valid {
+		order.CancelReason = &models.CancelReason{
+			Code:    contracts.ReasonCode(cancelCode.String),
+			Message: cancelMessage.String,
+		}
+	}
+	if order.Price, err = money.ParseSigned(price, money.Currency(currency)); err != nil {
+		return nil, fmt.Errorf("order %s: %w", order.ID, err)
+	}
 	return &order, nil
 }
 
-// GetByUserID возвращает заказы пользователя
-func (r *OrderRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Order, error) {
+// LockStatus возвращает статус заказа и блокирует заказ до конца транзакции exec,
+// чтобы параллельные переходы проверялись по актуальному статусу
+func (r *OrderRepository) LockStatus(ctx context.Context, exec db.Executor, id uuid.UUID) (models.OrderStatus, error) {
+	query := `SELECT status FROM orders WHERE id = $1 FOR UPDATE`
+
+	var status models.OrderStatus
+	err := exec.QueryRowContext(ctx, query, id).Scan(&status)
+	return status, err
+}
+
+// Lock возвращает заказ без позиций и блокирует его до конца транзакции exec (см. LockStatus)
+func (r *OrderRepository) Lock(ctx context.Context, exec db.Executor, id uuid.UUID) (*models.Order, error) {
 	query := `
-        SELECT id, user_id, price, description, status, created_at, updated_at
+        SELECT id, user_id, price, currency, description, status, cancel_reason_code, cancel_reason, created_at, updated_at
         FROM orders
-        WHERE user_id = $1
-        ORDER BY created_at DESC
+        WHERE id = $1
+        FOR UPDATE
+    `
+
+	return scanOrder(exec.QueryRowContext(ctx, query, id))
+}
+
+// UpdateStatus обновляет статус заказа; допустимость перехода проверяет OrderService.
+// exec позволяет выполнить обновление внутри транзакции
+func (r *OrderRepository) UpdateStatus(ctx context.Context, exec db.Executor, id uuid.UUID, status models.OrderStatus) error {
+	query := `
+        UPDATE orders
+        SET status = $1, updated_at = $2
+        WHERE id = $3
+    `
+
+	_, err := exec.ExecContext(ctx, query, status, time.Now(), id)
+	return err
+}
+
+// SetCancelReason сохраняет причину отмены заказа; exec позволяет выполнить обновление внутри транзакции
+func (r *OrderRepository) SetCancelReason(ctx context.Context, exec db.Executor, id uuid.UUID, reason models.CancelReason) error {
+	query := `
+        UPDATE orders
+        SET cancel_reason_code = $1, cancel_reason = $2
+        WHERE id = $3
+    `
+
+	_, err := exec.ExecContext(ctx, query, reason.Code, reason.Message, id)
+	return err
+}
+
+// AddStatusChange записывает переход в историю статусов заказа
+func (r *OrderRepository) AddStatusChange(ctx context.Context, exec db.Executor, change *models.StatusChange) error {
+	change.CreatedAt = time.Now()
+
+	query := `
+        INSERT INTO order_status_history (order_id, from_status, to_status, reason, reason_code, message_id, created_at)
+        VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7)
+        RETURNING id
+    `
+
+	return exec.QueryRowContext(ctx, query,
+		change.OrderID,
+		change.From,
+		change.To,
+		change.Reason,
+		change.ReasonCode,
+		change.MessageID,
+		change.CreatedAt,
+	).Scan(&change.ID)
+}
+
+// GetStatusHistory возвращает историю статусов заказа в порядке переходов
+func (r *OrderRepository) GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]models.StatusChange, error) {
+	query := `
+        SELECT id, order_id, COALESCE(from_status, ''), to_status, reason, COALESCE(reason_code, ''), COALESCE(message_id, ''), created_at
+        FROM order_status_history
+        WHERE order_id = $1
+        ORDER BY id
     `
 
-	rows, err := db.Query(ctx, query, userID)
+	rows, err := db.Query(ctx, query, orderID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orders []models.Order
+	history := []models.StatusChange{}
 	for rows.Next() {
-		var order models.Order
+		var change models.StatusChange
 		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.Price,
-			&order.Description,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
+			&change.ID,
+			&change.OrderID,
+			&change.From,
+			&change.To,
+			&change.Reason,
+			&change.ReasonCode,
+			&change.MessageID,
+			&change.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, order)
+		history = append(history, change)
 	}
 
-	return orders, nil
+	return history, rows.Err()
+}
+
+// LastStatusChangeID возвращает id последней записи истории статусов (0, если история пуста)
+func (r *OrderRepository) LastStatusChangeID(ctx context.Context) (int64, error) {
+	var id int64
+	err := db.QueryRow(ctx, `SELECT COALESCE(max(id), 0) FROM order_status_history`).Scan(&id)
+	return id, err
 }
 
-// UpdateStatus обновляет статус заказа
-func (r *OrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+// GetStatusEventsAfter возвращает до limit переходов всех заказов с id больше afterID или из ids
+// в порядке id
+func (r *OrderRepository) GetStatusEventsAfter(ctx context.Context, afterID int64, ids []int64, limit int) ([]models.StatusEvent, error) {
 	query := `
-        UPDATE orders
-        SET status = $1, updated_at = $2
-        WHERE id = $3
+        SELECT h.id, h.order_id, o.user_id, COALESCE(h.from_status, ''), h.to_status, h.reason, COALESCE(h.reason_code, ''), COALESCE(h.message_id, ''), h.created_at
+        FROM order_status_history h
+        JOIN orders o ON o.id = h.order_id
+        WHERE h.id > $1 OR h.id = ANY($2)
+        ORDER BY h.id
+        LIMIT $3
     `
 
-	_, err := db.Exec(ctx, query, status, time.Now(), id)
-	return err
+	return r.queryStatusEvents(ctx, query, afterID, pq.Array(ids), limit)
+}
+
+// GetUserStatusEvents возвращает до limit переходов заказов пользователя с id больше afterID в порядке id
+func (r *OrderRepository) GetUserStatusEvents(ctx context.Context, userID uuid.UUID, afterID int64, limit int) ([]models.StatusEvent, error) {
+	query := `
+        SELECT h.id, h.order_id, o.user_id, COALESCE(h.from_status, ''), h.to_status, h.reason, COALESCE(h.reason_code, ''), COALESCE(h.message_id, ''), h.created_at
+        FROM order_status_history h
+        JOIN orders o ON o.id = h.order_id
+        WHERE o.user_id = $1 AND h.id > $2
+        ORDER BY h.id
+        LIMIT $3
+    `
+
+	return r.queryStatusEvents(ctx, query, userID, afterID, limit)
+}
+
+func (r *OrderRepository) queryStatusEvents(ctx context.Context, query string, args ...any) ([]models.StatusEvent, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.StatusEvent
+	for rows.Next() {
+		var event models.StatusEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.OrderID,
+			&event.UserID,
+			&event.From,
+			&event.To,
+			&event.Reason,
+			&event.ReasonCode,
+			&event.MessageID,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetStaleByStatus возвращает до limit заказов в статусе status, который не менялся с before,
+// начиная с самых старых
+func (r *OrderRepository) GetStaleByStatus(ctx context.Context, status models.OrderStatus, before time.Time, limit int) ([]models.Order, error) {
+	query := `
+        SELECT id, user_id, price, currency, description, status, cancel_reason_code, cancel_reason, created_at, updated_at
+        FROM orders
+        WHERE status = $1 AND updated_at < $2
+        ORDER BY updated_at
+        LIMIT $3
+    `
+
+	rows, err := db.Query(ctx, query, status, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+
+	return orders, rows.Err()
 }
 
 // GetByStatus возвращает заказы по статусу
 func (r *OrderRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Order, error) {
 	query := `
-        SELECT id, user_id, price, description, status, created_at, updated_at
+        SELECT id, user_id, price, currency, description, status, cancel_reason_code, cancel_reason, created_at, updated_at
         FROM orders
         WHERE status = $1
         ORDER BY created_at DESC
@@ -134,59 +433,70 @@ func (r *OrderRepository) GetByStatus(ctx context.Context, status string, limit,
 
 	var orders []models.Order
 	for rows.Next() {
-		var order models.Order
-		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.Price,
-			&order.Description,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, order)
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return orders, nil
+	return r.withItems(ctx, orders)
 }
 
-// GetByUserAndStatus возвращает заказы пользователя по статусу
-func (r *OrderRepository) GetByUserAndStatus(ctx context.Context, userID uuid.UUID, status string) ([]models.Order, error) {
+// withItems загружает позиции заказов одним запросом
+func (r *OrderRepository) withItems(ctx context.Context, orders []models.Order) ([]models.Order, error) {
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]string, len(orders))
+	index := make(map[uuid.UUID]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID.String()
+		index[order.ID] = i
+		orders[i].Items = []models.OrderItem{}
+	}
+
 	query := `
-        SELECT id, user_id, price, description, status, created_at, updated_at
-        FROM orders
-        WHERE user_id = $1 AND status = $2
-        ORDER BY created_at DESC
+        SELECT id, order_id, product_id, sku, name, unit_price, quantity
+        FROM order_items
+        WHERE order_id = ANY($1::uuid[])
+        ORDER BY created_at, sku
     `
 
-	rows, err := db.Query(ctx, query, userID, status)
+	rows, err := db.Query(ctx, query, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orders []models.Order
 	for rows.Next() {
-		var order models.Order
+		var item models.OrderItem
+		var unitPrice string
 		err := rows.Scan(
-			&order.ID,
-			&order.UserID,
-			&order.Price,
-			&order.Description,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
+			&item.ID,
+			&item.OrderID,
+			&item.ProductID,
+			&item.SKU,
+			&item.Name,
+			&unitPrice,
+			&item.Quantity,
 		)
 		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, order)
+		i := index[item.OrderID]
+		// Цены позиций хранятся в валюте заказа
+		if item.UnitPrice, err = money.ParseSigned(unitPrice, orders[i].Price.Currency()); err != nil {
+			return nil, fmt.Errorf("order item %s: %w", item.ID, err)
+		}
+		orders[i].Items = append(orders[i].Items, item)
 	}
 
-	return orders, nil
+	return orders, rows.Err()
 }
 
 // Delete удаляет заказ
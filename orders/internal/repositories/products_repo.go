@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	models "sd_hw4/orders/internal/models"
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/money"
+
+	"github.com/lib/pq"
+)
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// ListActive возвращает товары, доступные для заказа
+func (r *ProductRepository) ListActive(ctx context.Context) ([]models.Product, error) {
+	query := `
+        SELECT id, sku, name, price, currency, active, created_at
+        FROM products
+        WHERE active
+        ORDER BY sku
+    `
+
+	return r.queryProducts(ctx, r.db, query)
+}
+
+// GetBySKUs возвращает товары с указанными артикулами, включая неактивные;
+// exec позволяет выполнить выборку внутри транзакции создания заказа
+func (r *ProductRepository) GetBySKUs(ctx context.Context, exec db.Executor, skus []string) ([]models.Product, error) {
+	query := `
+        SELECT id, sku, name, price, currency, active, created_at
+        FROM products
+        WHERE sku = ANY($1)
+    `
+
+	return r.queryProducts(ctx, exec, query, pq.Array(skus))
+}
+
+func (r *ProductRepository) queryProducts(ctx context.Context, exec db.Executor, query string, args ...any) ([]models.Product, error) {
+	rows, err := exec.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var product models.Product
+		var price, currency string
+		err := rows.Scan(
+			&product.ID,
+			&product.SKU,
+			&product.Name,
+			&price,
+			&currency,
+			&product.Active,
+			&product.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if product.Price, err = money.ParseSigned(price, money.Currency(currency)); err != nil {
+			return nil, fmt.Errorf("product %s: %w", product.SKU, err)
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	models "sd_hw4/orders/internal/models"
+	"sd_hw4/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// Размер страницы списка заказов
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// OrderPage — страница списка заказов. NextCursor пуст на последней странице
+type OrderPage struct {
+	Orders     []models.Order
+	NextCursor string
+}
+
+// pageCursor — содержимое курсора страницы. Порядок сортировки сохраняется в курсоре,
+// чтобы курсор, выданный для одного порядка, нельзя было применить к другому
+type pageCursor struct {
+	Sort      models.OrderSort `json:"sort"`
+	CreatedAt time.Time        `json:"created_at"`
+	Price     money.Money      `json:"price"`
+	ID        uuid.UUID        `json:"id"`
+}
+
+// GetOrdersByUser возвращает страницу заказов пользователя, подходящих под filter.
+// cursor — NextCursor предыдущей страницы (пустой для первой); фильтры и порядок
+// при переходе между страницами не должны меняться. Ошибки параметров возвращаются как ErrInvalidQuery
+func (s *OrderService) GetOrdersByUser(ctx context.Context, userID uuid.UUID, filter models.OrderFilter, cursor string) (*OrderPage, error) {
+	if err := normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		after, err := decodeCursor(cursor, filter.Sort)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
+	// Лишний заказ показывает, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+	orders, err := s.orderRepo.ListByUser(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		last := page.Orders[pageSize-1]
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:      filter.Sort,
+			CreatedAt: last.CreatedAt,
+			Price:     last.Price,
+			ID:        last.ID,
+		})
+	}
+	return page, nil
+}
+
+// normalizeFilter проверяет параметры выборки и подставляет значения по умолчанию
+func normalizeFilter(filter *models.OrderFilter) error {
+	if filter.Sort == "" {
+		filter.Sort = models.OrderSortCreatedDesc
+	}
+	if !filter.Sort.Valid() {
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, filter.Sort)
+	}
+	for _, status := range filter.Statuses {
+		if !status.Valid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, status)
+		}
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit < 1 || filter.Limit > maxPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, maxPageSize)
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return fmt.Errorf("%w: created_from must be before created_to", ErrInvalidQuery)
+	}
+	if filter.MinPrice != nil && filter.MinPrice.IsNegative() || filter.MaxPrice != nil && filter.MaxPrice.IsNegative() {
+		return fmt.Errorf("%w: price bounds must not be negative", ErrInvalidQuery)
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil {
+		cmp, err := filter.MinPrice.Compare(*filter.MaxPrice)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+		if cmp > 0 {
+			return fmt.Errorf("%w: min_price must not exceed max_price", ErrInvalidQuery)
+		}
+	}
+	return nil
+}
+
+// encodeCursor кодирует позицию в непрозрачную для клиента строку
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, sort models.OrderSort) (*models.OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidQuery, cursor.Sort)
+	}
+	return &models.OrderCursor{CreatedAt: cursor.CreatedAt, Price: cursor.Price, ID: cursor.ID}, nil
+}
@@ -2,112 +2,475 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	models "sd_hw4/orders/internal/models"
 	"sd_hw4/orders/internal/repositories"
-	"sd_hw4/pkg/messaging"
+	"sd_hw4/pkg/config"
+	"sd_hw4/pkg/contracts"
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/inbox"
+	"sd_hw4/pkg/money"
+	"sd_hw4/pkg/outbox"
+	"sd_hw4/pkg/webhooks"
 
 	"github.com/google/uuid"
 )
 
+// Ограничения на состав заказа
+const (
+	maxOrderItems   = 100
+	maxItemQuantity = 1000
+	// maxOrderPrice — наибольшая сумма заказа в копейках, которая помещается в столбец
+	// orders.price decimal(10,2)
+	maxOrderPrice = 99_999_999_99
+)
+
+// ErrInvalidOrder — заказ нельзя создать из-за ошибки клиента (пустой состав,
+// неизвестный товар, некорректное количество)
+var ErrInvalidOrder = errors.New("invalid order")
+
+// ErrInvalidQuery — некорректные параметры списка заказов (фильтры, порядок, курсор)
+var ErrInvalidQuery = errors.New("invalid order query")
+
+// ErrIllegalTransition — переход между статусами заказа не разрешен (см. OrderStatus.CanTransitionTo)
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
 type OrderService struct {
-	orderRepo  repositories.OrderRepository
-	outboxRepo repositories.OutboxRepository
-	publisher  *messaging.Publisher
+	db            *sql.DB
+	orderRepo     repositories.OrderRepository
+	productRepo   *repositories.ProductRepository
+	outboxStore   outbox.Store
+	paymentTarget config.PublishTarget
 }
 
-func NewOrderService(orderRepo *repositories.OrderRepository, outboxRepo *repositories.OutboxRepository, publisher *messaging.Publisher) *OrderService {
+func NewOrderService(
+	conn *sql.DB,
+	orderRepo *repositories.OrderRepository,
+	productRepo *repositories.ProductRepository,
+	outboxStore outbox.Store,
+	paymentTarget config.PublishTarget,
+) *OrderService {
 	return &OrderService{
-		orderRepo:  *orderRepo,
-		outboxRepo: *outboxRepo,
-		publisher:  publisher,
+		db:            conn,
+		orderRepo:     *orderRepo,
+		productRepo:   productRepo,
+		outboxStore:   outboxStore,
+		paymentTarget: paymentTarget,
 	}
 }
 
-// CreateOrder создает новый заказ и сообщение для оплаты
-func (s *OrderService) CreateOrder(ctx context.Context, userID uuid.UUID, amount float64, description string) (*models.Order, error) {
+// ListProducts возвращает товары, доступные для заказа
+func (s *OrderService) ListProducts(ctx context.Context) ([]models.Product, error) {
+	return s.productRepo.ListActive(ctx)
+}
+
+// CreateOrder создает заказ из позиций каталога и сообщение для оплаты.
+// Сумма заказа считается по текущим ценам каталога; ошибки состава возвращаются как ErrInvalidOrder
+func (s *OrderService) CreateOrder(ctx context.Context, userID uuid.UUID, items []models.ItemRequest, description string) (*models.Order, error) {
+	quantities, skus, err := mergeItems(items)
+	if err != nil {
+		return nil, err
+	}
+
 	// Создаем заказ
 	order := &models.Order{
 		ID:          uuid.New(),
 		UserID:      userID,
-		Price:       amount,
 		Description: description,
 		Status:      string(models.OrderStatusNew),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	// Сохраняем заказ в БД
-	if err := s.orderRepo.Create(ctx, order); err != nil {
-		return nil, fmt.Errorf("failed to save order: %w", err)
+	// Заказ и задача на оплату сохраняются в одной транзакции (Transactional Outbox)
+	err = db.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		products, err := s.productRepo.GetBySKUs(ctx, tx, skus)
+		if err != nil {
+			return fmt.Errorf("failed to load products: %w", err)
+		}
+		if err := fillItems(order, products, skus, quantities); err != nil {
+			return err
+		}
+
+		if err := s.orderRepo.Create(ctx, tx, order); err != nil {
+			return fmt.Errorf("failed to save order: %w", err)
+		}
+		created := &models.StatusChange{OrderID: order.ID, To: models.OrderStatusNew, Reason: "order created"}
+		if err := s.orderRepo.AddStatusChange(ctx, tx, created); err != nil {
+			return fmt.Errorf("failed to save order status history: %w", err)
+		}
+
+		paymentRequest := contracts.PaymentRequested{
+			OrderID:     order.ID.String(),
+			UserID:      userID.String(),
+			Amount:      order.Price,
+			Description: description,
+			Timestamp:   order.CreatedAt.Format(time.RFC3339),
+		}
+
+		outboxMsg, err := outbox.NewMessage(s.paymentTarget.Exchange, s.paymentTarget.RoutingKey,
+			contracts.TypePaymentRequested, paymentRequest)
+		if err != nil {
+			return err
+		}
+		// Все сообщения заказа публикуются в порядке записи
+		outboxMsg.ForAggregate(order.ID.String())
+
+		if err := s.outboxStore.Add(ctx, tx, outboxMsg); err != nil {
+			return fmt.Errorf("failed to save outbox message: %w", err)
+		}
+
+		// Запрос на оплату записан и будет отправлен relay
+		err = s.TransitionStatus(ctx, tx, order.ID, models.OrderStatusPaymentPending, "payment requested", nil, outboxMsg.MessageID)
+		if err != nil {
+			return err
+		}
+		order.Status = string(models.OrderStatusPaymentPending)
+
+		if err := s.addWebhookEvent(ctx, tx, order, contracts.TypeOrderCreated, "order created"); err != nil {
+			return err
+		}
+
+		log.Printf("Created outbox message %s for order %s", outboxMsg.MessageID, order.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Создаем сообщение для оплаты
-	paymentRequest := models.PaymentRequest{
-		OrderID:     order.ID,
-		UserID:      userID,
-		Price:       amount,
-		Description: description,
+	return order, nil
+}
+
+// mergeItems проверяет позиции и складывает количество повторяющихся артикулов.
+// Возвращает количество по артикулам и артикулы в порядке первого появления
+func mergeItems(items []models.ItemRequest) (map[string]int, []string, error) {
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("%w: order must contain at least one item", ErrInvalidOrder)
+	}
+	if len(items) > maxOrderItems {
+		return nil, nil, fmt.Errorf("%w: order must contain at most %d items", ErrInvalidOrder, maxOrderItems)
 	}
 
-	payload, err := json.Marshal(paymentRequest)
+	quantities := make(map[string]int, len(items))
+	var skus []string
+	for _, item := range items {
+		if item.SKU == "" {
+			return nil, nil, fmt.Errorf("%w: item sku is required", ErrInvalidOrder)
+		}
+		if item.Quantity <= 0 {
+			return nil, nil, fmt.Errorf("%w: quantity of %q must be positive", ErrInvalidOrder, item.SKU)
+		}
+		if _, ok := quantities[item.SKU]; !ok {
+			skus = append(skus, item.SKU)
+		}
+		quantities[item.SKU] += item.Quantity
+		if quantities[item.SKU] > maxItemQuantity {
+			return nil, nil, fmt.Errorf("%w: quantity of %q must not exceed %d", ErrInvalidOrder, item.SKU, maxItemQuantity)
+		}
+	}
+	return quantities, skus, nil
+}
+
+// fillItems заполняет позиции заказа по каталогу и считает сумму заказа. Суммы хранятся
+// в копейках (pkg/money), поэтому ошибка округления не накапливается. Все товары заказа
+// должны быть в одной валюте, она становится валютой заказа. Сумма, которая не помещается
+// в orders.price, отклоняется как ошибка клиента
+func fillItems(order *models.Order, products []models.Product, skus []string, quantities map[string]int) error {
+	bySKU := make(map[string]models.Product, len(products))
+	for _, product := range products {
+		bySKU[product.SKU] = product
+	}
+
+	order.Items = make([]models.OrderItem, 0, len(skus))
+	for i, sku := range skus {
+		product, ok := bySKU[sku]
+		if !ok || !product.Active {
+			return fmt.Errorf("%w: product %q is not available", ErrInvalidOrder, sku)
+		}
+		if i == 0 {
+			order.Price = money.Zero(product.Price.Currency())
+		}
+
+		item := models.OrderItem{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			UnitPrice: product.Price,
+			Quantity:  quantities[sku],
+		}
+		amount, err := item.Amount()
+		if err != nil {
+			return fmt.Errorf("%w: amount of %q: %v", ErrInvalidOrder, sku, err)
+		}
+		if order.Price, err = order.Price.Add(amount); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+		}
+		order.Items = append(order.Items, item)
+	}
+	if order.Price.Minor() > maxOrderPrice {
+		return fmt.Errorf("%w: order total %s exceeds %s", ErrInvalidOrder,
+			order.Price, money.FromMinor(maxOrderPrice, order.Price.Currency()))
+	}
+	return nil
+}
+
+// GetOrderByID возвращает заказ по ID
+func (s *OrderService) GetOrderByID(ctx context.Context, orderID uuid.UUID) (*models.Order, error) {
+	return s.orderRepo.GetByID(ctx, orderID)
+}
+
+// GetStatusHistory возвращает историю статусов заказа или sql.ErrNoRows, если заказа нет
+func (s *OrderService) GetStatusHistory(ctx context.Context, orderID uuid.UUID) ([]models.StatusChange, error) {
+	history, err := s.orderRepo.GetStatusHistory(ctx, orderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payment request: %w", err)
+		return nil, err
 	}
+	if len(history) == 0 {
+		// У каждого заказа есть хотя бы запись о создании
+		return nil, sql.ErrNoRows
+	}
+	return history, nil
+}
 
-	if !json.Valid(payload) {
-		return nil, fmt.Errorf("invalid JSON payload")
+// TransitionStatus переводит заказ в статус to и записывает переход в историю с причиной
+// и идентификатором вызвавшего его сообщения. cancelReason передается для перехода, который
+// отменяет заказ: она сохраняется в заказе, а ее код — в истории; для остальных переходов nil.
+// Заказ блокируется до конца транзакции exec. Переход в текущий статус (повторное сообщение)
+// ничего не меняет; недопустимый переход возвращает ErrIllegalTransition, отсутствующий заказ — sql.ErrNoRows
+func (s *OrderService) TransitionStatus(ctx context.Context, exec db.Executor, orderID uuid.UUID, to models.OrderStatus, reason string, cancelReason *models.CancelReason, messageID string) error {
+	order, err := s.orderRepo.Lock(ctx, exec, orderID)
+	if err != nil {
+		return err
+	}
+	from := models.OrderStatus(order.Status)
+	if from == to {
+		inbox.Effect(ctx, "order %s is already %s, nothing to change", orderID, to)
+		return nil
+	}
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: order %s from %s to %s", ErrIllegalTransition, orderID, from, to)
 	}
 
-	// Сохраняем в outbox
-	outboxMsg := &models.OutboxMessage{
-		ID:         uuid.New(),
-		MessageID:  uuid.New().String(),
-		Exchange:   "payments",
-		RoutingKey: "payment.request",
-		Payload:    json.RawMessage(payload),
-		Headers:    json.RawMessage(`{}`),
-		Status:     models.StatusPending,
-		CreatedAt:  time.Now(),
-		RetryCount: 0,
+	if err := s.orderRepo.UpdateStatus(ctx, exec, orderID, to); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	change := &models.StatusChange{OrderID: orderID, From: from, To: to, Reason: reason, MessageID: messageID}
+	if cancelReason != nil {
+		change.ReasonCode = cancelReason.Code
+		if err := s.orderRepo.SetCancelReason(ctx, exec, orderID, *cancelReason); err != nil {
+			return fmt.Errorf("failed to save cancel reason: %w", err)
+		}
+		order.CancelReason = cancelReason
 	}
+	if err := s.orderRepo.AddStatusChange(ctx, exec, change); err != nil {
+		return fmt.Errorf("failed to save order status history: %w", err)
+	}
+	inbox.Effect(ctx, "set order %s status %s -> %s (%s)", orderID, from, to, reason)
 
-	log.Println("Created outbox message:", outboxMsg)
+	if eventType, ok := webhookEvents[to]; ok {
+		order.Status = string(to)
+		if err := s.addWebhookEvent(ctx, exec, order, eventType, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	if err := s.outboxRepo.Create(ctx, outboxMsg); err != nil {
-		return nil, fmt.Errorf("failed to save outbox message: %w", err)
+// webhookEvents — события вебхуков, о которых сообщает переход в статус
+var webhookEvents = map[models.OrderStatus]string{
+	models.OrderStatusFinished: contracts.TypeOrderPaid,
+	models.OrderStatusCanceled: contracts.TypeOrderCanceled,
+	models.OrderStatusRefunded: contracts.TypeOrderRefunded,
+}
+
+// addWebhookEvent записывает в outbox событие вебхуков о заказе в той же транзакции, что и переход.
+// Relay передает его pkg/webhooks, который ставит доставки адресам пользователя
+func (s *OrderService) addWebhookEvent(ctx context.Context, exec db.Executor, order *models.Order, eventType, reason string) error {
+	event := contracts.OrderEvent{
+		OrderID:     order.ID.String(),
+		UserID:      order.UserID.String(),
+		Status:      order.Status,
+		Amount:      order.Price,
+		Description: order.Description,
+		Reason:      reason,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if order.CancelReason != nil {
+		event.ReasonCode = order.CancelReason.Code
 	}
 
-	return order, nil
+	outboxMsg, err := outbox.NewMessage(webhooks.Exchange, order.UserID.String(), eventType, event)
+	if err != nil {
+		return err
+	}
+	if err := s.outboxStore.Add(ctx, exec, outboxMsg); err != nil {
+		return fmt.Errorf("failed to save webhook event: %w", err)
+	}
+	return nil
 }
 
-// GetOrdersByUser возвращает заказы пользователя
-func (s *OrderService) GetOrdersByUser(ctx context.Context, userID uuid.UUID) ([]models.Order, error) {
-	return s.orderRepo.GetByUserID(ctx, userID)
+// ProcessPaymentResult обрабатывает результат оплаты в транзакции обработчика inbox.
+// Повторный или опоздавший результат (replay, повторная доставка, заказ уже завершен sweeper)
+// ничего не меняет. В карантин помещается только результат, который противоречит статусу заказа,
+// например отказ в оплате для уже оплаченного заказа
+func (s *OrderService) ProcessPaymentResult(ctx context.Context, tx *sql.Tx, msg inbox.Message, paymentResult contracts.PaymentResult) error {
+	orderID, err := uuid.Parse(paymentResult.OrderID)
+	if err != nil {
+		return inbox.Permanent(fmt.Errorf("invalid order id %q: %w", paymentResult.OrderID, err))
+	}
+
+	current, err := s.orderRepo.LockStatus(ctx, tx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return inbox.Permanent(fmt.Errorf("order %s not found", orderID))
+	}
+	if err != nil {
+		return err
+	}
+	if current == models.OrderStatusCanceled {
+		// Заказ отменен, пока оплата была в пути: при отмене отправлен запрос на возврат,
+		// который payments обработает после этого списания
+		inbox.Effect(ctx, "order %s is canceled, payment %s is compensated by refund", orderID, paymentResult.Status)
+		return nil
+	}
+	succeeded := paymentResult.Status == contracts.PaymentSucceeded
+	if succeeded && (current == models.OrderStatusRefundPending || current == models.OrderStatusRefunded) {
+		// Оплата уже учтена: заказ был оплачен и затем отменен с возвратом. Результат пришел
+		// повторно (replay, повторная доставка) или уже после того, как заказ завершил sweeper
+		inbox.Effect(ctx, "order %s is already %s, late payment result is ignored", orderID, current)
+		return nil
+	}
+
+	status, reason := models.OrderStatusFinished, "payment succeeded"
+	var cancelReason *models.CancelReason
+	if !succeeded {
+		cancelReason = paymentFailure(paymentResult.ReasonCode, paymentResult.Reason)
+		status, reason = models.OrderStatusCanceled, "payment failed: "+cancelReason.Message
+	}
+
+	err = s.TransitionStatus(ctx, tx, orderID, status, reason, cancelReason, msg.MessageID)
+	if errors.Is(err, ErrIllegalTransition) || errors.Is(err, sql.ErrNoRows) {
+		return inbox.Permanent(err)
+	}
+	return err
 }
 
-// GetOrderByID возвращает заказ по ID
-func (s *OrderService) GetOrderByID(ctx context.Context, orderID uuid.UUID) (*models.Order, error) {
+// paymentFailure возвращает причину отмены заказа из-за отказа в оплате. В результатах,
+// отправленных до появления кодов причин, кода нет — он подбирается по тексту; незнакомый
+// код заменяется на ReasonUnknown с сохранением текста
+func paymentFailure(code contracts.ReasonCode, message string) *models.CancelReason {
+	if code == "" {
+		code = contracts.ReasonCodeOf(message)
+	}
+	if !code.Valid() {
+		code = contracts.ReasonUnknown
+	}
+	if message == "" {
+		message = code.Message()
+	}
+	return &models.CancelReason{Code: code, Message: message}
+}
+
+// CancelOrder отменяет заказ по запросу пользователя. Неоплаченный заказ сразу становится
+// canceled, а еще не отправленный запрос на оплату отменяется. Оплаченный заказ переходит
+// в refund_pending и станет refunded после подтверждения возврата от payments.
+// В обоих случаях в outbox записывается запрос на возврат: он публикуется после запроса
+// на оплату этого заказа, поэтому оплата, которая уже в пути, тоже будет возвращена.
+// Для заказа в другом статусе возвращает ErrIllegalTransition, для отсутствующего — sql.ErrNoRows
+func (s *OrderService) CancelOrder(ctx context.Context, orderID uuid.UUID) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		status, err := s.orderRepo.LockStatus(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		return s.cancel(ctx, tx, order, status, contracts.ReasonCanceledByUser)
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return s.orderRepo.GetByID(ctx, orderID)
 }
 
-// UpdateOrderStatus обновляет статус заказа
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, orderID uuid.UUID, status models.OrderStatus) error {
-	return s.orderRepo.UpdateStatus(ctx, orderID, string(status))
+// cancel отменяет заблокированный заказ в статусе status (см. CancelOrder) по причине code
+// и записывает в outbox запрос на возврат
+func (s *OrderService) cancel(ctx context.Context, tx *sql.Tx, order *models.Order, status models.OrderStatus, code contracts.ReasonCode) error {
+	reason := code.Message()
+	var next models.OrderStatus
+	transitionReason := reason
+	switch status {
+	case models.OrderStatusPaymentPending:
+		next = models.OrderStatusCanceled
+		discarded, err := s.outboxStore.DiscardUnsent(ctx, tx, order.ID.String(), contracts.TypePaymentRequested)
+		if err != nil {
+			return fmt.Errorf("failed to discard payment request: %w", err)
+		}
+		if discarded > 0 {
+			transitionReason += ", payment request is not sent"
+		}
+	case models.OrderStatusFinished:
+		next, transitionReason = models.OrderStatusRefundPending, reason+", refund requested"
+	default:
+		return fmt.Errorf("%w: order %s is %s and cannot be canceled", ErrIllegalTransition, order.ID, status)
+	}
+
+	refundRequest := contracts.RefundRequested{
+		OrderID:    order.ID.String(),
+		UserID:     order.UserID.String(),
+		ReasonCode: code,
+		Reason:     reason,
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	outboxMsg, err := outbox.NewMessage(s.paymentTarget.Exchange, s.paymentTarget.RoutingKey,
+		contracts.TypeRefundRequested, refundRequest)
+	if err != nil {
+		return err
+	}
+	if err := s.outboxStore.Add(ctx, tx, outboxMsg.ForAggregate(order.ID.String())); err != nil {
+		return fmt.Errorf("failed to save outbox message: %w", err)
+	}
+
+	cancelReason := &models.CancelReason{Code: code, Message: reason}
+	return s.TransitionStatus(ctx, tx, order.ID, next, transitionReason, cancelReason, outboxMsg.MessageID)
 }
 
-// ProcessPaymentResult обрабатывает результат оплаты
-func (s *OrderService) ProcessPaymentResult(ctx context.Context, paymentResult models.PaymentResult) error {
-	var status models.OrderStatus
-	if paymentResult.Success {
-		status = models.OrderStatusFinished
-	} else {
-		status = models.OrderStatusCanceled
+// ProcessRefundResult обрабатывает результат возврата в транзакции обработчика inbox.
+// Неудачный возврат (например, счет удален) помещается в карантин: деньги не вернулись,
+// нужно решение оператора
+func (s *OrderService) ProcessRefundResult(ctx context.Context, tx *sql.Tx, msg inbox.Message, refundResult contracts.RefundResult) error {
+	orderID, err := uuid.Parse(refundResult.OrderID)
+	if err != nil {
+		return inbox.Permanent(fmt.Errorf("invalid order id %q: %w", refundResult.OrderID, err))
+	}
+	if refundResult.Status != contracts.PaymentSucceeded {
+		return inbox.Permanent(fmt.Errorf("refund for order %s failed: %s", orderID, refundResult.Reason))
 	}
 
-	return s.UpdateOrderStatus(ctx, paymentResult.OrderID, status)
+	current, err := s.orderRepo.LockStatus(ctx, tx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return inbox.Permanent(fmt.Errorf("order %s not found", orderID))
+	}
+	if err != nil {
+		return err
+	}
+	if current == models.OrderStatusCanceled {
+		// Отмена до оплаты: возвращена оплата, которая была в пути, или возвращать нечего
+		inbox.Effect(ctx, "order %s is canceled, refunded %s", orderID, refundResult.Amount)
+		return nil
+	}
+
+	err = s.TransitionStatus(ctx, tx, orderID, models.OrderStatusRefunded, "refund completed", nil, msg.MessageID)
+	if errors.Is(err, ErrIllegalTransition) {
+		return inbox.Permanent(err)
+	}
+	return err
 }
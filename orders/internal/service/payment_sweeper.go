@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	models "sd_hw4/orders/internal/models"
+	"sd_hw4/pkg/contracts"
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/tunables"
+
+	"github.com/google/uuid"
+)
+
+// PaymentOutcomes — источник окончательного итога оплаты заказа (журнал оплат payments)
+type PaymentOutcomes interface {
+	// Debit возвращает списание по заказу или nil, если запрос на оплату еще не обработан
+	Debit(ctx context.Context, orderID uuid.UUID) (*contracts.PaymentOperation, error)
+}
+
+// PaymentSweeper находит заказы, которые ждут результата оплаты дольше deadline
+// (сообщение потеряно, payments недоступен), и завершает их по итогу из payments
+// или отменяет (см. OrderService.ResolvePaymentTimeout)
+type PaymentSweeper struct {
+	orders   *OrderService
+	payments PaymentOutcomes
+	deadline time.Duration
+	tunables *tunables.Processor
+}
+
+// NewPaymentSweeper создает обработчик; payments может быть nil — тогда итог не уточняется
+// и просроченные заказы сразу отменяются
+func NewPaymentSweeper(orders *OrderService, payments PaymentOutcomes, deadline time.Duration, tunables *tunables.Processor) *PaymentSweeper {
+	return &PaymentSweeper{
+		orders:   orders,
+		payments: payments,
+		deadline: deadline,
+		tunables: tunables,
+	}
+}
+
+// Run запускает поиск просроченных заказов по расписанию; завершается при отмене ctx
+func (s *PaymentSweeper) Run(ctx context.Context) {
+	s.tunables.Run(ctx, nil, s.Sweep)
+	log.Println("Payment sweeper stopped")
+}
+
+// Sweep обрабатывает до batchSize самых старых просроченных заказов
+func (s *PaymentSweeper) Sweep(ctx context.Context, batchSize int) {
+	orders, err := s.orders.orderRepo.GetStaleByStatus(ctx, models.OrderStatusPaymentPending, time.Now().Add(-s.deadline), batchSize)
+	if err != nil {
+		log.Printf("Failed to find orders waiting for payment: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			return
+		}
+		status, err := s.orders.ResolvePaymentTimeout(ctx, &order, s.debit(ctx, order.ID))
+		if err != nil {
+			log.Printf("Failed to resolve payment timeout for order %s: %v", order.ID, err)
+			continue
+		}
+		log.Printf("Order %s waited for payment longer than %s, now %s", order.ID, s.deadline, status)
+	}
+}
+
+// debit уточняет итог оплаты у payments; при ошибке итог считается неизвестным
+func (s *PaymentSweeper) debit(ctx context.Context, orderID uuid.UUID) *contracts.PaymentOperation {
+	if s.payments == nil {
+		return nil
+	}
+	debit, err := s.payments.Debit(ctx, orderID)
+	if err != nil {
+		log.Printf("Failed to get payment outcome for order %s, canceling it: %v", orderID, err)
+		return nil
+	}
+	return debit
+}
+
+// ResolvePaymentTimeout завершает заказ, который не дождался результата оплаты, и возвращает
+// его новый статус. debit — списание из журнала payments: успешное завершает заказ, неудачное
+// отменяет его. Если итог неизвестен (debit == nil), заказ отменяется с причиной ReasonPaymentTimeout
+// и в outbox записывается запрос на возврат: payments обработает его после запроса на оплату,
+// поэтому списание, которое произойдет позже, будет возвращено, а еще не выполненное — запрещено.
+// Заказ, который тем временем получил результат или был отменен, не меняется
+func (s *OrderService) ResolvePaymentTimeout(ctx context.Context, order *models.Order, debit *contracts.PaymentOperation) (models.OrderStatus, error) {
+	var result models.OrderStatus
+	err := db.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		status, err := s.orderRepo.LockStatus(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+		result = status
+		if status != models.OrderStatusPaymentPending {
+			return nil
+		}
+
+		switch {
+		case debit == nil:
+			result = models.OrderStatusCanceled
+			return s.cancel(ctx, tx, order, status, contracts.ReasonPaymentTimeout)
+		case debit.Status == contracts.PaymentSucceeded:
+			result = models.OrderStatusFinished
+			return s.TransitionStatus(ctx, tx, order.ID, result, "payment succeeded, confirmed by payments after timeout", nil, "")
+		default:
+			// Неудачное списание окончательно: payments не спишет деньги по этому заказу
+			result = models.OrderStatusCanceled
+			cancelReason := paymentFailure(debit.ReasonCode, debit.Reason)
+			return s.TransitionStatus(ctx, tx, order.ID, result,
+				"payment failed: "+cancelReason.Message+", confirmed by payments after timeout", cancelReason, "")
+		}
+	})
+	return result, err
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	models "sd_hw4/orders/internal/models"
+	"sd_hw4/orders/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+// StatusEventsChannel — канал Postgres NOTIFY, в который триггер сообщает о новых записях
+// истории статусов заказов
+const StatusEventsChannel = "order_status_history"
+
+const (
+	// statusEventsPollInterval — проверка новых записей без уведомления (уведомление потеряно
+	// или подписка на канал не удалась)
+	statusEventsPollInterval = 5 * time.Second
+	statusEventsBatch        = 500
+	// subscriberBuffer — сколько событий может ждать медленный клиент; при переполнении
+	// поток закрывается, и клиент переподключается с Last-Event-ID
+	subscriberBuffer = 64
+	// gapTimeout — сколько ждать запись истории с пропущенным id. Транзакция, получившая
+	// меньший id, может зафиксироваться позже транзакции с большим, а откатившаяся
+	// не зафиксируется никогда
+	gapTimeout = time.Minute
+	maxGap     = 1000
+)
+
+// StatusEvents рассылает переходы статусов заказов подписчикам (потокам SSE) этой реплики.
+// Источник событий — таблица order_status_history: переход, зафиксированный любой репликой,
+// будит все реплики через NOTIFY, и каждая читает новые записи сама
+type StatusEvents struct {
+	repo *repositories.OrderRepository
+	wake <-chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan models.StatusEvent]struct{}
+	closed      bool
+
+	// lastID и gaps использует только Run
+	lastID int64
+	gaps   map[int64]time.Time
+}
+
+// NewStatusEvents создает рассылку; wake — уведомления канала StatusEventsChannel, может быть nil
+func NewStatusEvents(repo *repositories.OrderRepository, wake <-chan struct{}) *StatusEvents {
+	return &StatusEvents{
+		repo:        repo,
+		wake:        wake,
+		subscribers: make(map[uuid.UUID]map[chan models.StatusEvent]struct{}),
+		gaps:        make(map[int64]time.Time),
+	}
+}
+
+// Subscribe подписывает на переходы заказов пользователя, зафиксированные после подписки.
+// Канал закрывается при отписке, переполнении буфера и остановке рассылки
+func (e *StatusEvents) Subscribe(userID uuid.UUID) (<-chan models.StatusEvent, func()) {
+	ch := make(chan models.StatusEvent, subscriberBuffer)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if e.subscribers[userID] == nil {
+		e.subscribers[userID] = make(map[chan models.StatusEvent]struct{})
+	}
+	e.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.remove(userID, ch)
+	}
+}
+
+// Close закрывает все подписки, например перед остановкой HTTP-сервера: иначе открытые
+// потоки не дадут ему завершиться
+func (e *StatusEvents) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	for userID, channels := range e.subscribers {
+		for ch := range channels {
+			e.remove(userID, ch)
+		}
+	}
+}
+
+// remove вызывается под e.mu
+func (e *StatusEvents) remove(userID uuid.UUID, ch chan models.StatusEvent) {
+	channels := e.subscribers[userID]
+	if _, ok := channels[ch]; !ok {
+		return
+	}
+	delete(channels, ch)
+	close(ch)
+	if len(channels) == 0 {
+		delete(e.subscribers, userID)
+	}
+}
+
+// Run читает новые записи истории по уведомлениям и по таймеру; завершается при отмене ctx
+func (e *StatusEvents) Run(ctx context.Context) {
+	defer e.Close()
+
+	ticker := time.NewTicker(statusEventsPollInterval)
+	defer ticker.Stop()
+
+	// События, зафиксированные до запуска, клиенты получают из истории по Last-Event-ID
+	for {
+		lastID, err := e.repo.LastStatusChangeID(ctx)
+		if err == nil {
+			e.lastID = lastID
+			break
+		}
+		log.Printf("Failed to read order status history position: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order status events stopped")
+			return
+		case <-e.wake:
+		case <-ticker.C:
+		}
+		if err := e.poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to read order status events: %v", err)
+		}
+	}
+}
+
+func (e *StatusEvents) poll(ctx context.Context) error {
+	for {
+		now := time.Now()
+		gaps := make([]int64, 0, len(e.gaps))
+		for id, since := range e.gaps {
+			if now.Sub(since) > gapTimeout {
+				delete(e.gaps, id)
+				continue
+			}
+			gaps = append(gaps, id)
+		}
+
+		events, err := e.repo.GetStatusEventsAfter(ctx, e.lastID, gaps, statusEventsBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			delete(e.gaps, event.ID)
+			if event.ID > e.lastID {
+				if event.ID-e.lastID <= maxGap {
+					for id := e.lastID + 1; id < event.ID; id++ {
+						e.gaps[id] = now
+					}
+				}
+				e.lastID = event.ID
+			}
+			e.publish(event)
+		}
+
+		if len(events) < statusEventsBatch {
+			return nil
+		}
+	}
+}
+
+func (e *StatusEvents) publish(event models.StatusEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ch := range e.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			// Клиент не успевает читать: закрываем поток, клиент продолжит с Last-Event-ID
+			e.remove(event.UserID, ch)
+		}
+	}
+}
+
+// GetUserStatusEvents возвращает до limit переходов заказов пользователя после события afterID
+func (s *OrderService) GetUserStatusEvents(ctx context.Context, userID uuid.UUID, afterID int64, limit int) ([]models.StatusEvent, error) {
+	return s.orderRepo.GetUserStatusEvents(ctx, userID, afterID, limit)
+}
@@ -0,0 +1,16 @@
+-- Ключи идемпотентности (заголовок Idempotency-Key): запрос, выполненный с ключом,
+-- и сохраненный ответ, который возвращается на повтор того же запроса
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+  "id" bigserial PRIMARY KEY,
+  "scope" varchar(200) NOT NULL,
+  "key" varchar(255) NOT NULL,
+  "request_hash" varchar(64) NOT NULL,
+  "status_code" int,
+  "content_type" varchar(100) NOT NULL DEFAULT '',
+  "response" bytea,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "completed_at" timestamp,
+  UNIQUE ("scope", "key")
+);
+
+CREATE INDEX IF NOT EXISTS "idempotency_keys_created_at_idx" ON "idempotency_keys" ("created_at");
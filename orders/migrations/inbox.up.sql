@@ -14,4 +14,48 @@ CREATE INDEX ON "inbox_messages" ("processed", "created_at");
 
 CREATE INDEX ON "inbox_messages" ("queue");
 
-CREATE INDEX ON "inbox_messages" ("created_at");
\ No newline at end of file
+CREATE INDEX ON "inbox_messages" ("created_at");
+
+-- Уведомление о новых строках: обработчик inbox слушает канал inbox_messages и забирает
+-- сообщения сразу, не дожидаясь очередного опроса. Уведомление доставляется после фиксации транзакции.
+CREATE OR REPLACE FUNCTION "notify_inbox_messages"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('inbox_messages', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "inbox_messages_notify" ON "inbox_messages";
+CREATE TRIGGER "inbox_messages_notify" AFTER INSERT ON "inbox_messages"
+  FOR EACH STATEMENT EXECUTE FUNCTION "notify_inbox_messages"();
+
+-- Сообщение, отброшенное оператором через административный API: считается обработанным
+-- и продолжает защищать от повторной доставки
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "discarded_at" timestamp;
+
+-- Неудачная обработка повторяется не раньше next_attempt_at (экспоненциальная задержка).
+-- После исчерпания попыток сообщение помещается в карантин (quarantined_at) и больше не
+-- обрабатывается автоматически, не задерживая более новые сообщения очереди
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "attempts" integer NOT NULL DEFAULT 0;
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "last_error" text;
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "next_attempt_at" timestamp NOT NULL DEFAULT (now());
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "quarantined_at" timestamp;
+
+CREATE INDEX IF NOT EXISTS "inbox_messages_due_idx" ON "inbox_messages" ("queue", "next_attempt_at")
+  WHERE "processed" = false AND "quarantined_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "inbox_messages_quarantined_idx" ON "inbox_messages" ("created_at")
+  WHERE "quarantined_at" IS NOT NULL AND "processed" = false;
+
+-- Несколько экземпляров обработчика захватывают пачки строк: строка принадлежит
+-- claimed_by до lease_until, после истечения аренды ее может забрать другой экземпляр.
+-- Сообщения с одним ordering_key (id заказа отправителя) обрабатываются строго по seq
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "claimed_by" varchar(100);
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "lease_until" timestamp;
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "ordering_key" varchar(100);
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "seq" bigserial;
+
+CREATE INDEX IF NOT EXISTS "inbox_messages_ordering_idx" ON "inbox_messages" ("queue", "ordering_key", "seq")
+  WHERE "processed" = false AND "quarantined_at" IS NULL;
+
+-- Тип сообщения из свойства AMQP Type: обработчик выбирается по очереди и типу
+ALTER TABLE "inbox_messages" ADD COLUMN IF NOT EXISTS "message_type" varchar(100);
@@ -1 +1,6 @@
+DROP TRIGGER IF EXISTS order_status_history_notify ON order_status_history;
+DROP FUNCTION IF EXISTS notify_order_status_history();
+DROP TABLE IF EXISTS order_status_history;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS products;
 DROP TABLE IF EXISTS orders;
\ No newline at end of file
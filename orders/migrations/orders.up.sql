@@ -11,3 +11,119 @@ CREATE TABLE IF NOT EXISTS "orders" (
 CREATE INDEX ON "orders" ("user_id");
 CREATE INDEX ON "orders" ("status");
 CREATE INDEX ON "orders" ("user_id", "status");
+
+
+-- Каталог товаров. Цена заказа считается сервисом по позициям, клиент передает только
+-- артикулы и количество. Неактивный товар нельзя заказать, но он остается в старых заказах
+CREATE TABLE IF NOT EXISTS "products" (
+  "id" uuid PRIMARY KEY,
+  "sku" varchar(64) UNIQUE NOT NULL,
+  "name" text NOT NULL,
+  "price" decimal(10,2) NOT NULL CHECK ("price" >= 0),
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+-- Позиции заказа. Название и цена копируются из каталога на момент заказа,
+-- поэтому изменение каталога не меняет уже созданные заказы
+CREATE TABLE IF NOT EXISTS "order_items" (
+  "id" uuid PRIMARY KEY,
+  "order_id" uuid NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "product_id" uuid NOT NULL REFERENCES "products" ("id"),
+  "sku" varchar(64) NOT NULL,
+  "name" text NOT NULL,
+  "unit_price" decimal(10,2) NOT NULL,
+  "quantity" integer NOT NULL CHECK ("quantity" > 0),
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "order_items_order_id_idx" ON "order_items" ("order_id");
+
+INSERT INTO "products" ("id", "sku", "name", "price") VALUES
+  ('6f1c2a8e-3b7d-4c1e-9a5f-0d2e4b6c8a01', 'BOOK-001', 'Книга «Go на практике»', 50.00),
+  ('6f1c2a8e-3b7d-4c1e-9a5f-0d2e4b6c8a02', 'MUG-001', 'Кружка', 15.50),
+  ('6f1c2a8e-3b7d-4c1e-9a5f-0d2e4b6c8a03', 'TSHIRT-001', 'Футболка', 120.00)
+ON CONFLICT ("sku") DO NOTHING;
+
+
+-- История статусов заказа: каждый переход с причиной и идентификатором сообщения,
+-- которое его вызвало. from_status пуст у записи о создании заказа
+CREATE TABLE IF NOT EXISTS "order_status_history" (
+  "id" bigserial PRIMARY KEY,
+  "order_id" uuid NOT NULL REFERENCES "orders" ("id") ON DELETE CASCADE,
+  "from_status" varchar(20),
+  "to_status" varchar(20) NOT NULL,
+  "reason" text NOT NULL DEFAULT '',
+  "message_id" varchar(100),
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "order_status_history_order_idx" ON "order_status_history" ("order_id", "id");
+
+-- Заказы в статусе new, созданные до появления payment_pending, уже ждут результата оплаты.
+-- Новые заказы переходят в payment_pending в транзакции создания, поэтому обновление
+-- при повторном запуске миграций ничего не затрагивает
+UPDATE "orders" SET "status" = 'payment_pending' WHERE "status" = 'new';
+
+INSERT INTO "order_status_history" ("order_id", "to_status", "reason", "created_at")
+SELECT o."id", o."status", 'history started', COALESCE(o."updated_at", now())
+FROM "orders" o
+WHERE NOT EXISTS (SELECT 1 FROM "order_status_history" h WHERE h."order_id" = o."id");
+
+-- Постраничный список заказов пользователя выбирается по ключу (created_at, id) или (price, id),
+-- поэтому created_at не может быть пустым
+UPDATE "orders" SET "created_at" = COALESCE("updated_at", now()) WHERE "created_at" IS NULL;
+ALTER TABLE "orders" ALTER COLUMN "created_at" SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "orders_user_created_idx" ON "orders" ("user_id", "created_at", "id");
+CREATE INDEX IF NOT EXISTS "orders_user_price_idx" ON "orders" ("user_id", "price", "id");
+
+-- Уведомление о новых переходах: поток событий (GET /orders/{user_id}/events) на каждой реплике
+-- слушает канал order_status_history и читает новые записи истории сразу после фиксации транзакции
+CREATE OR REPLACE FUNCTION "notify_order_status_history"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('order_status_history', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "order_status_history_notify" ON "order_status_history";
+CREATE TRIGGER "order_status_history_notify" AFTER INSERT ON "order_status_history"
+  FOR EACH STATEMENT EXECUTE FUNCTION "notify_order_status_history"();
+
+-- Валюта суммы заказа и цены товара: суммы хранятся в decimal и читаются вместе с валютой
+-- (см. pkg/money). Цены позиций заказа — в валюте заказа
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'RUB';
+
+-- Причина отмены заказа: стандартный код (contracts.ReasonCode) и текст для пользователя.
+-- Код причины сохраняется и в записи истории о переходе, который отменяет заказ
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "cancel_reason_code" varchar(50);
+ALTER TABLE "orders" ADD COLUMN IF NOT EXISTS "cancel_reason" text;
+ALTER TABLE "order_status_history" ADD COLUMN IF NOT EXISTS "reason_code" varchar(50);
+
+-- Отменам, записанным раньше, код подбирается по тексту причины в истории
+UPDATE "order_status_history" SET "reason_code" = CASE
+    WHEN "reason" LIKE 'canceled by user%' THEN 'canceled_by_user'
+    WHEN "reason" LIKE 'payment_timeout%' THEN 'payment_timeout'
+    WHEN "reason" LIKE 'payment failed: insufficient funds%' THEN 'insufficient_funds'
+    WHEN "reason" LIKE 'payment failed: no bill found for user%' THEN 'no_bill'
+    WHEN "reason" LIKE 'payment failed: no active bill found%' THEN 'no_active_bill'
+    WHEN "reason" LIKE 'payment failed: invalid %' THEN 'invalid_request'
+    WHEN "reason" LIKE 'payment failed: currency mismatch%' THEN 'currency_mismatch'
+    WHEN "reason" LIKE 'payment failed: order canceled%' THEN 'order_canceled'
+    ELSE 'unknown'
+  END
+WHERE "to_status" IN ('canceled', 'refund_pending') AND "reason_code" IS NULL;
+
+UPDATE "orders" o
+SET "cancel_reason_code" = h."reason_code",
+    "cancel_reason" = regexp_replace(h."reason",
+      '^payment failed: |, (payment request is not sent|refund requested|confirmed by payments after timeout)$', '', 'g')
+FROM (
+  SELECT DISTINCT ON ("order_id") "order_id", "reason", "reason_code"
+  FROM "order_status_history"
+  WHERE "to_status" IN ('canceled', 'refund_pending')
+  ORDER BY "order_id", "id" DESC
+) h
+WHERE h."order_id" = o."id" AND o."cancel_reason_code" IS NULL;
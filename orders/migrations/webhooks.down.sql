@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP FUNCTION IF EXISTS notify_webhook_deliveries();
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,51 @@
+-- Адреса вебхуков: получатель событий заказов владельца (пользователя) и секрет подписи
+CREATE TABLE IF NOT EXISTS "webhook_endpoints" (
+  "id" uuid PRIMARY KEY,
+  "owner_id" varchar(100) NOT NULL,
+  "url" text NOT NULL,
+  "secret" varchar(200) NOT NULL,
+  -- Пустой список — все события
+  "events" text[] NOT NULL DEFAULT '{}',
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_endpoints_owner_idx" ON "webhook_endpoints" ("owner_id");
+
+-- Доставки: событие для одного адреса. Тело события сохраняется при постановке в очередь,
+-- поэтому повторы и ручная повторная доставка отправляют те же байты. Строка принадлежит
+-- claimed_by до lease_until, неудачная попытка повторяется не раньше next_attempt_at
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" uuid NOT NULL REFERENCES "webhook_endpoints" ("id") ON DELETE CASCADE,
+  "event_id" varchar(100) NOT NULL,
+  "event_type" varchar(100) NOT NULL,
+  "payload" bytea NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamp NOT NULL DEFAULT (now()),
+  "last_attempt_at" timestamp,
+  "last_status_code" integer,
+  "last_error" text,
+  "delivered_at" timestamp,
+  "claimed_by" varchar(100),
+  "lease_until" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  -- Повторная публикация события из outbox не создает вторую доставку
+  UNIQUE ("endpoint_id", "event_id")
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_due_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" IN ('pending', 'failed');
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_endpoint_idx" ON "webhook_deliveries" ("endpoint_id", "id");
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_created_at_idx" ON "webhook_deliveries" ("created_at");
+
+-- Уведомление о новых доставках: диспетчер слушает канал webhook_deliveries
+CREATE OR REPLACE FUNCTION "notify_webhook_deliveries"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('webhook_deliveries', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "webhook_deliveries_notify" ON "webhook_deliveries";
+CREATE TRIGGER "webhook_deliveries_notify" AFTER INSERT ON "webhook_deliveries"
+  FOR EACH STATEMENT EXECUTE FUNCTION "notify_webhook_deliveries"();
@@ -14,13 +14,22 @@ import (
 	handlers "sd_hw4/payments/internal/handler"
 	"sd_hw4/payments/internal/repositories"
 	"sd_hw4/payments/internal/services"
+	"sd_hw4/pkg/admin"
 	"sd_hw4/pkg/config"
+	"sd_hw4/pkg/contracts"
 	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/idempotency"
+	"sd_hw4/pkg/inbox"
 	"sd_hw4/pkg/messaging"
+	"sd_hw4/pkg/outbox"
+	"sd_hw4/pkg/retention"
+	"sd_hw4/pkg/tunables"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,26 +43,32 @@ func main() {
 	logger.SetLevel(logrus.InfoLevel)
 
 	// Загрузка конфигурации
-	cfg := config.Load()
+	cfg, err := config.LoadPayments(os.Args[1:])
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	logger.WithField("config", cfg).Info("Configuration loaded")
 
 	// Инициализация базы данных
 	logger.Println("Connecting to database...")
-	if err := db.Connect(cfg.DatabaseURL); err != nil {
+	if err := db.Connect(cfg.DB.DSN.Value()); err != nil {
 		logger.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
 	logger.Println("Database connected successfully")
 	// Выполнение миграций
-	if err := db.Migrate(cfg.MigrationsDir); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if cfg.DB.RunMigrations {
+		if err := db.Migrate(cfg.DB.MigrationsDir); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
 	}
 
 	// Инициализация RabbitMQ
 	mqConfig := messaging.ConnectionConfig{
-		URL:            cfg.RabbitMQURL,
-		MaxReconnects:  10,
-		ReconnectDelay: 5 * time.Second,
+		URL:            cfg.Broker.URL.Value(),
+		MaxReconnects:  cfg.Broker.MaxReconnects,
+		ReconnectDelay: cfg.Broker.ReconnectDelay,
 	}
 
 	mqConn := messaging.NewConnection(mqConfig)
@@ -67,25 +82,59 @@ func main() {
 
 	// Инициализация репозиториев
 	billRepo := repositories.NewBillRepository(db.DB)
-	inboxRepo := repositories.NewInboxRepo(db.DB)
-	outboxRepo := repositories.NewOutboxRepository(db.DB)
+	inboxStore := inbox.NewPostgresStore(db.DB)
+	outboxStore := outbox.NewPostgresStore(db.DB)
+
+	// Уведомления Postgres будят обработчики сразу после вставки строк, опрос остается страховкой
+	notifier := db.NewNotifier(cfg.DB.DSN.Value())
+	// В режиме cdc новые строки outbox приходят из WAL, уведомления relay не нужны
+	var outboxWake <-chan struct{}
+	if cfg.Outbox.Mode != "cdc" {
+		outboxWake = subscribe(logger, notifier, outbox.NotifyChannel)
+	}
+
+	// Настраиваемые во время работы параметры фоновых обработчиков; новые значения проверяются
+	// вместе с остальной конфигурацией, как при старте
+	processors := tunables.NewRegistry(func(candidate map[string]config.ProcessorConfig) error {
+		next := *cfg
+		for name, processor := range candidate {
+			next.Processors.Set(name, processor)
+		}
+		return next.Validate()
+	})
+	outboxTunables := processors.Register("outbox_relay", cfg.Processors.OutboxRelay)
+	paymentTunables := processors.Register("payment_processor", cfg.Processors.PaymentProcessor)
+	retentionTunables := processors.Register("retention", cfg.Processors.Retention)
 
 	// Инициализация сервисов
 	billService := services.NewBillService(billRepo)
-	messageService := services.NewMessageService(inboxRepo, outboxRepo, mqConn.Conn)
-	paymentService := services.NewPaymentService(billService, messageService)
+	messageService := services.NewMessageService(inboxStore)
+	paymentService := services.NewPaymentService(db.DB, billService, repositories.NewOperationRepository(), outboxStore, cfg.Outbox.PaymentResult)
 
 	orderConsumer := handlers.NewOrderConsumerHandler(
 		queueManager,
 		logger,
-		"orders.payment_requests",
-		"payments.payment_results",
+		cfg.Broker.Consumer,
+		cfg.ResultsQueue,
+		cfg.Outbox.PaymentResult,
 	)
 
+	// Обработчики входящих сообщений по очереди и типу; без обработчика сохраненные
+	// консьюмером сообщения никто не обработает, поэтому сервис не запускается
+	paymentProcessor := services.NewPaymentProcessor(paymentService)
+	dispatcher := inbox.NewDispatcher()
+	dispatcher.Register(orderConsumer.InboxQueue(), contracts.TypePaymentRequested, inbox.JSON(paymentProcessor.HandlePaymentRequest))
+	dispatcher.Register(orderConsumer.InboxQueue(), contracts.TypeRefundRequested, inbox.JSON(paymentProcessor.HandleRefundRequest))
+	if err := dispatcher.Validate(orderConsumer.InboxQueue()); err != nil {
+		logger.Fatalf("Invalid inbox configuration: %v", err)
+	}
+
 	// Запуск обработчика входящих сообщений
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go notifier.Run(ctx)
+
 	// Настраиваем очереди
 	if err := orderConsumer.SetupQueue(ctx); err != nil {
 		logger.Fatal("Failed to setup queues:", err)
@@ -96,16 +145,64 @@ func main() {
 		logger.Fatal("Failed to start consumer:", err)
 	}
 
-	// Инициализация и запуск payment processor
-	paymentProcessor := services.NewPaymentProcessor(
-		paymentService,
-		messageService,
+	// Запуск обработчиков inbox
+	inboxProcessors := dispatcher.Processors(
+		db.DB,
+		inboxStore,
+		paymentTunables,
+		inbox.ProcessorOptions{
+			Owner:    cfg.InstanceID,
+			Lease:    cfg.Inbox.Lease,
+			Ordering: inbox.Ordering(cfg.Inbox.Ordering),
+			Retry:    cfg.Inbox.Retry.Policy(),
+			Hooks:    inbox.NewMetrics("payments", prometheus.DefaultRegisterer).Hooks(),
+		},
+		// Каждый процессор подписывается на уведомления сам: уведомление будит всех подписчиков
+		func(string) <-chan struct{} { return subscribe(logger, notifier, inbox.NotifyChannel) },
+	)
+	inbox.RegisterStateGauge("payments", prometheus.DefaultRegisterer, inboxStore)
+	for _, processor := range inboxProcessors {
+		go processor.Run(ctx)
+	}
+
+	// Инициализация и запуск outbox relay
+	outboxRelay := outbox.NewRelay(
+		outboxStore,
+		mqConn,
+		outboxTunables,
+		outbox.RelayOptions{
+			Owner: cfg.InstanceID,
+			Lease: cfg.Outbox.Lease,
+			Retry: cfg.Outbox.Retry.Policy(),
+			Wake:  outboxWake,
+			Hooks: outbox.NewMetrics("payments", prometheus.DefaultRegisterer).Hooks(),
+		},
 	)
-	go paymentProcessor.ProcessMessages(ctx)
+	outbox.RegisterStatusGauge("payments", prometheus.DefaultRegisterer, outboxStore)
 
-	// Инициализация и запуск message sender
-	messageSender := services.NewMessageSender(messageService)
-	go messageSender.Start(ctx)
+	// Очистка отправленных сообщений outbox, обработанных сообщений inbox и устаревших ключей идемпотентности
+	retentionPolicies := []retention.Policy{
+		retention.OutboxPolicy(retention.Mode(cfg.Retention.Outbox.Mode), cfg.Retention.Outbox.MaxAge),
+		retention.InboxPolicy(retention.Mode(cfg.Retention.Inbox.Mode), cfg.Retention.Inbox.MaxAge),
+		retention.IdempotencyPolicy(cfg.Idempotency.TTL),
+	}
+	retentionCleaner := retention.NewCleaner(
+		db.DB,
+		retentionTunables,
+		retention.NewMetrics("payments", prometheus.DefaultRegisterer, db.DB, retention.Tables(retentionPolicies...)...).Hooks(),
+		retentionPolicies...,
+	)
+	go outboxRelay.Run(ctx)
+	if cfg.Outbox.Mode == "cdc" {
+		// Опросный relay остается для повторов и строк, пропущенных потоком изменений
+		cdcRelay := outbox.NewCDCRelay(outboxRelay, db.DB, outbox.CDCOptions{
+			DSN:         cfg.DB.DSN.Value(),
+			Slot:        cfg.Outbox.CDC.Slot,
+			Publication: cfg.Outbox.CDC.Publication,
+		})
+		go cdcRelay.Run(ctx)
+	}
+	go retentionCleaner.Run(ctx)
 
 	// Создание Echo сервера
 	e := echo.New()
@@ -137,9 +234,54 @@ func main() {
 		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
 	}))
 
+	// Повтор запроса с тем же Idempotency-Key возвращает сохраненный ответ, а не выполняет его снова
+	e.Use(idempotency.Middleware(idempotency.NewStore(db.DB), idempotency.Options{
+		Routes:      []string{"/add/:bill_id"},
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Idempotency.LockTimeout,
+	}))
+
 	handler := handlers.NewHandler(billService, paymentService)
 	payments.RegisterHandlers(e, handler)
 
+	// Административные маршруты доступны только с токеном; без токена они не регистрируются
+	if cfg.Admin.Token != "" {
+		auditLog := admin.NewAuditLog(db.DB)
+		adminGroup := e.Group("/admin", admin.RequireToken(cfg.Admin.Token.Value()))
+		tunables.NewHandler(processors).RegisterRoutes(adminGroup)
+		outbox.NewHandler(outboxStore, auditLog).RegisterRoutes(adminGroup)
+		inbox.NewHandler(db.DB, inboxStore, auditLog, dispatcher).RegisterRoutes(adminGroup)
+		auditLog.RegisterRoutes(adminGroup)
+	} else {
+		logger.Warn("Admin API is disabled: admin.token is not set")
+	}
+
+	// Перечитывание конфигурации по SIGHUP применяет параметры обработчиков без перезапуска
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			reloaded, err := config.LoadPayments(os.Args[1:])
+			if err != nil {
+				logger.WithError(err).Error("Failed to reload configuration, keeping current settings")
+				continue
+			}
+			if err := outboxTunables.Apply(reloaded.Processors.OutboxRelay, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply outbox relay settings")
+			}
+			if err := retentionTunables.Apply(reloaded.Processors.Retention, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply retention settings")
+			}
+			if err := paymentTunables.Apply(reloaded.Processors.PaymentProcessor, tunables.SourceReload); err != nil {
+				logger.WithError(err).Error("Failed to apply payment processor settings")
+			}
+			logger.WithField("processors", processors.Snapshot()).Info("Configuration reloaded")
+		}
+	}()
+
+	// Метрики Prometheus
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
 	// Health check endpoint
 	e.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{
@@ -148,7 +290,7 @@ func main() {
 		})
 	})
 
-	if err := e.Start(":" + cfg.ServerPort); err != nil && err != http.ErrServerClosed {
+	if err := e.Start(":" + cfg.HTTP.Port); err != nil && err != http.ErrServerClosed {
 		logger.Fatal("Failed to start HTTP server:", err)
 	}
 
@@ -161,7 +303,7 @@ func main() {
 	logger.Info("Shutting down server...")
 
 	// Graceful shutdown с таймаутом
-	ctx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, shutdownCancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer shutdownCancel()
 
 	// Остановка HTTP сервера
@@ -174,8 +316,18 @@ func main() {
 	queueManager.StopAllConsumers()
 
 	// Откат миграций при необходимости
-	db.Rollback(cfg.MigrationsDir)
+	db.Rollback(cfg.DB.MigrationsDir)
 
 	time.Sleep(2 * time.Second)
 	logger.Info("Server stopped gracefully")
 }
+
+// subscribe подписывается на уведомления канала; при ошибке обработчик работает только по таймеру.
+func subscribe(logger *logrus.Logger, notifier *db.Notifier, channel string) <-chan struct{} {
+	wake, err := notifier.Subscribe(channel)
+	if err != nil {
+		logger.WithError(err).Warnf("Failed to listen on %s, falling back to polling", channel)
+		return nil
+	}
+	return wake
+}
@@ -12,31 +12,86 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
+
+	"sd_hw4/pkg/money"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
 )
 
-// PostAddBillIdJSONBody defines parameters for PostAddBillId.
-type PostAddBillIdJSONBody struct {
-	// Amount ╨б╤Г╨╝╨╝╨░ ╨┐╨╛╨┐╨╛╨╗╨╜╨╡╨╜╨╕╤П
-	Amount float32 `json:"amount"`
+// Defines values for PaymentOperationKind.
+const (
+	Debit  PaymentOperationKind = "debit"
+	Refund PaymentOperationKind = "refund"
+)
+
+// Defines values for PaymentOperationReasonCode.
+const (
+	BillNotFound      PaymentOperationReasonCode = "bill_not_found"
+	CurrencyMismatch  PaymentOperationReasonCode = "currency_mismatch"
+	InsufficientFunds PaymentOperationReasonCode = "insufficient_funds"
+	InvalidRequest    PaymentOperationReasonCode = "invalid_request"
+	NoActiveBill      PaymentOperationReasonCode = "no_active_bill"
+	NoBill            PaymentOperationReasonCode = "no_bill"
+	NotPaid           PaymentOperationReasonCode = "not_paid"
+	OrderCanceled     PaymentOperationReasonCode = "order_canceled"
+	Unknown           PaymentOperationReasonCode = "unknown"
+)
+
+// Defines values for PaymentOperationStatus.
+const (
+	Failed  PaymentOperationStatus = "failed"
+	Success PaymentOperationStatus = "success"
+)
+
+// Money Денежная сумма. amount — десятичная строка с не более чем двумя знаками после запятой (в запросах допускается и число), отрицательные суммы не принимаются; currency — код валюты ISO 4217, по умолчанию RUB
+type Money = money.Money
+
+// PaymentOperation defines model for PaymentOperation.
+type PaymentOperation struct {
+	// Amount Денежная сумма. amount — десятичная строка с не более чем двумя знаками после запятой (в запросах допускается и число), отрицательные суммы не принимаются; currency — код валюты ISO 4217, по умолчанию RUB
+	Amount Money `json:"amount"`
+
+	// Kind debit — списание оплаты, refund — возврат
+	Kind    PaymentOperationKind `json:"kind"`
+	OrderId string               `json:"order_id"`
+
+	// Reason Причина отказа
+	Reason *string `json:"reason,omitempty"`
+
+	// ReasonCode Стандартный код причины отказа (у возврата без оплаты — not_paid); текст причины — в reason
+	ReasonCode *PaymentOperationReasonCode `json:"reason_code,omitempty"`
+	Status     PaymentOperationStatus      `json:"status"`
+	Timestamp  time.Time                   `json:"timestamp"`
 }
 
+// PaymentOperationKind debit — списание оплаты, refund — возврат
+type PaymentOperationKind string
+
+// PaymentOperationReasonCode Стандартный код причины отказа (у возврата без оплаты — not_paid); текст причины — в reason
+type PaymentOperationReasonCode string
+
+// PaymentOperationStatus defines model for PaymentOperation.Status.
+type PaymentOperationStatus string
+
 // PostAddBillIdParams defines parameters for PostAddBillId.
 type PostAddBillIdParams struct {
-	// UserId ID ╨┐╨╛╨╗╤М╨╖╨╛╨▓╨░╤В╨╡╨╗╤П
+	// UserId ID пользователя
 	UserId string `form:"user_id" json:"user_id"`
+
+	// IdempotencyKey Ключ идемпотентности (например, UUID). Повтор запроса с тем же ключом и телом возвращает сохраненный ответ с заголовком Idempotent-Replayed: true
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
 }
 
 // GetBalanceBillIdParams defines parameters for GetBalanceBillId.
 type GetBalanceBillIdParams struct {
-	// UserId ID ╨┐╨╛╨╗╤М╨╖╨╛╨▓╨░╤В╨╡╨╗╤П
+	// UserId ID пользователя
 	UserId string `form:"user_id" json:"user_id"`
 }
 
 // PostAddBillIdJSONRequestBody defines body for PostAddBillId for application/json ContentType.
-type PostAddBillIdJSONRequestBody PostAddBillIdJSONBody
+type PostAddBillIdJSONRequestBody = Money
 
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
@@ -121,6 +176,9 @@ type ClientInterface interface {
 
 	// PostCreateUserId request
 	PostCreateUserId(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOperationsOrderId request
+	GetOperationsOrderId(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) PostAddBillIdWithBody(ctx context.Context, billId string, params *PostAddBillIdParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -171,6 +229,18 @@ func (c *Client) PostCreateUserId(ctx context.Context, userId string, reqEditors
 	return c.Client.Do(req)
 }
 
+func (c *Client) GetOperationsOrderId(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOperationsOrderIdRequest(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 // NewPostAddBillIdRequest calls the generic PostAddBillId builder with application/json body
 func NewPostAddBillIdRequest(server string, billId string, params *PostAddBillIdParams, body PostAddBillIdJSONRequestBody) (*http.Request, error) {
 	var bodyReader io.Reader
@@ -233,6 +303,21 @@ func NewPostAddBillIdRequestWithBody(server string, billId string, params *PostA
 
 	req.Header.Add("Content-Type", contentType)
 
+	if params != nil {
+
+		if params.IdempotencyKey != nil {
+			var headerParam0 string
+
+			headerParam0, err = runtime.StyleParamWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, *params.IdempotencyKey)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Idempotency-Key", headerParam0)
+		}
+
+	}
+
 	return req, nil
 }
 
@@ -322,6 +407,40 @@ func NewPostCreateUserIdRequest(server string, userId string) (*http.Request, er
 	return req, nil
 }
 
+// NewGetOperationsOrderIdRequest generates requests for GetOperationsOrderId
+func NewGetOperationsOrderIdRequest(server string, orderId string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/operations/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
 	for _, r := range c.RequestEditors {
 		if err := r(ctx, req); err != nil {
@@ -375,15 +494,19 @@ type ClientWithResponsesInterface interface {
 
 	// PostCreateUserIdWithResponse request
 	PostCreateUserIdWithResponse(ctx context.Context, userId string, reqEditors ...RequestEditorFn) (*PostCreateUserIdResponse, error)
+
+	// GetOperationsOrderIdWithResponse request
+	GetOperationsOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetOperationsOrderIdResponse, error)
 }
 
 type PostAddBillIdResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 	JSON200      *struct {
-		Balance *float32 `json:"balance,omitempty"`
-		BillId  *string  `json:"bill_id,omitempty"`
-		UserId  *string  `json:"user_id,omitempty"`
+		// Balance Денежная сумма. amount — десятичная строка с не более чем двумя знаками после запятой (в запросах допускается и число), отрицательные суммы не принимаются; currency — код валюты ISO 4217, по умолчанию RUB
+		Balance *Money  `json:"balance,omitempty"`
+		BillId  *string `json:"bill_id,omitempty"`
+		UserId  *string `json:"user_id,omitempty"`
 	}
 }
 
@@ -407,9 +530,10 @@ type GetBalanceBillIdResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 	JSON200      *struct {
-		Balance *float32 `json:"balance,omitempty"`
-		BillId  *string  `json:"bill_id,omitempty"`
-		UserId  *string  `json:"user_id,omitempty"`
+		// Balance Денежная сумма. amount — десятичная строка с не более чем двумя знаками после запятой (в запросах допускается и число), отрицательные суммы не принимаются; currency — код валюты ISO 4217, по умолчанию RUB
+		Balance *Money  `json:"balance,omitempty"`
+		BillId  *string `json:"bill_id,omitempty"`
+		UserId  *string `json:"user_id,omitempty"`
 	}
 }
 
@@ -433,7 +557,7 @@ type PostCreateUserIdResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 	JSON200      *struct {
-		// BillId ID ╤Б╨╛╨╖╨┤╨░╨╜╨╜╨╛╨│╨╛ ╤Б╤З╨╡╤В╨░
+		// BillId ID созданного счета
 		BillId *string `json:"bill_id,omitempty"`
 		Status *string `json:"status,omitempty"`
 		UserId *string `json:"user_id,omitempty"`
@@ -456,6 +580,28 @@ func (r PostCreateUserIdResponse) StatusCode() int {
 	return 0
 }
 
+type GetOperationsOrderIdResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]PaymentOperation
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOperationsOrderIdResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOperationsOrderIdResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 // PostAddBillIdWithBodyWithResponse request with arbitrary body returning *PostAddBillIdResponse
 func (c *ClientWithResponses) PostAddBillIdWithBodyWithResponse(ctx context.Context, billId string, params *PostAddBillIdParams, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostAddBillIdResponse, error) {
 	rsp, err := c.PostAddBillIdWithBody(ctx, billId, params, contentType, body, reqEditors...)
@@ -491,6 +637,15 @@ func (c *ClientWithResponses) PostCreateUserIdWithResponse(ctx context.Context,
 	return ParsePostCreateUserIdResponse(rsp)
 }
 
+// GetOperationsOrderIdWithResponse request returning *GetOperationsOrderIdResponse
+func (c *ClientWithResponses) GetOperationsOrderIdWithResponse(ctx context.Context, orderId string, reqEditors ...RequestEditorFn) (*GetOperationsOrderIdResponse, error) {
+	rsp, err := c.GetOperationsOrderId(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOperationsOrderIdResponse(rsp)
+}
+
 // ParsePostAddBillIdResponse parses an HTTP response from a PostAddBillIdWithResponse call
 func ParsePostAddBillIdResponse(rsp *http.Response) (*PostAddBillIdResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -507,9 +662,10 @@ func ParsePostAddBillIdResponse(rsp *http.Response) (*PostAddBillIdResponse, err
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
 		var dest struct {
-			Balance *float32 `json:"balance,omitempty"`
-			BillId  *string  `json:"bill_id,omitempty"`
-			UserId  *string  `json:"user_id,omitempty"`
+			// Balance Денежная сумма. amount — десятичная строка с не более чем двумя знаками после запятой (в запросах допускается и число), отрицательные суммы не принимаются; currency — код валюты ISO 4217, по умолчанию RUB
+			Balance *Money  `json:"balance,omitempty"`
+			BillId  *string `json:"bill_id,omitempty"`
+			UserId  *string `json:"user_id,omitempty"`
 		}
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
@@ -537,9 +693,10 @@ func ParseGetBalanceBillIdResponse(rsp *http.Response) (*GetBalanceBillIdRespons
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
 		var dest struct {
-			Balance *float32 `json:"balance,omitempty"`
-			BillId  *string  `json:"bill_id,omitempty"`
-			UserId  *string  `json:"user_id,omitempty"`
+			// Balance Денежная сумма. amount — десятичная строка с не более чем двумя знаками после запятой (в запросах допускается и число), отрицательные суммы не принимаются; currency — код валюты ISO 4217, по умолчанию RUB
+			Balance *Money  `json:"balance,omitempty"`
+			BillId  *string `json:"bill_id,omitempty"`
+			UserId  *string `json:"user_id,omitempty"`
 		}
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
@@ -567,7 +724,7 @@ func ParsePostCreateUserIdResponse(rsp *http.Response) (*PostCreateUserIdRespons
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
 		var dest struct {
-			// BillId ID ╤Б╨╛╨╖╨┤╨░╨╜╨╜╨╛╨│╨╛ ╤Б╤З╨╡╤В╨░
+			// BillId ID созданного счета
 			BillId *string `json:"bill_id,omitempty"`
 			Status *string `json:"status,omitempty"`
 			UserId *string `json:"user_id,omitempty"`
@@ -582,17 +739,46 @@ func ParsePostCreateUserIdResponse(rsp *http.Response) (*PostCreateUserIdRespons
 	return response, nil
 }
 
+// ParseGetOperationsOrderIdResponse parses an HTTP response from a GetOperationsOrderIdWithResponse call
+func ParseGetOperationsOrderIdResponse(rsp *http.Response) (*GetOperationsOrderIdResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetOperationsOrderIdResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []PaymentOperation
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
-	// ╨Я╨╛╨┐╨╛╨╗╨╜╨╕╤В╤М ╨▒╨░╨╗╨░╨╜╤Б ╤Б╤З╨╡╤В╨░
+	// Пополнить баланс счета
 	// (POST /add/{bill_id})
 	PostAddBillId(ctx echo.Context, billId string, params PostAddBillIdParams) error
-	// ╨Я╨╛╨╗╤Г╤З╨╕╤В╤М ╨▒╨░╨╗╨░╨╜╤Б ╤Б╤З╨╡╤В╨░
+	// Получить баланс счета
 	// (GET /balance/{bill_id})
 	GetBalanceBillId(ctx echo.Context, billId string, params GetBalanceBillIdParams) error
-	// ╨б╨╛╨╖╨┤╨░╤В╤М ╤Б╤З╨╡╤В
+	// Создать счет
 	// (POST /create/{user_id})
 	PostCreateUserId(ctx echo.Context, userId string) error
+	// Получить операции по заказу
+	// (GET /operations/{order_id})
+	GetOperationsOrderId(ctx echo.Context, orderId string) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -620,6 +806,23 @@ func (w *ServerInterfaceWrapper) PostAddBillId(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter user_id: %s", err))
 	}
 
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
 	err = w.Handler.PostAddBillId(ctx, billId, params)
 	return err
@@ -666,6 +869,22 @@ func (w *ServerInterfaceWrapper) PostCreateUserId(ctx echo.Context) error {
 	return err
 }
 
+// GetOperationsOrderId converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOperationsOrderId(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "order_id" -------------
+	var orderId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "order_id", ctx.Param("order_id"), &orderId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter order_id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetOperationsOrderId(ctx, orderId)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -697,5 +916,6 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	router.POST(baseURL+"/add/:bill_id", wrapper.PostAddBillId)
 	router.GET(baseURL+"/balance/:bill_id", wrapper.GetBalanceBillId)
 	router.POST(baseURL+"/create/:user_id", wrapper.PostCreateUserId)
+	router.GET(baseURL+"/operations/:order_id", wrapper.GetOperationsOrderId)
 
 }
@@ -3,10 +3,10 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
-	"time"
 
 	"sd_hw4/payments/internal/services"
+	"sd_hw4/pkg/config"
+	"sd_hw4/pkg/inbox"
 	"sd_hw4/pkg/messaging"
 
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -17,65 +17,79 @@ import (
 type OrderConsumerHandler struct {
 	queueManager *messaging.QueueManager
 	logger       *logrus.Logger
+	consumer     config.ConsumerConfig
 	orderQueue   string
 	paymentQueue string
+	resultTarget config.PublishTarget
 }
 
-// NewOrderConsumerHandler создает новый обработчик
+// NewOrderConsumerHandler создает новый обработчик; результаты оплаты, публикуемые
+// в resultTarget, попадают в очередь paymentQueue
 func NewOrderConsumerHandler(
 	queueManager *messaging.QueueManager,
 	logger *logrus.Logger,
-	orderQueue string,
+	consumer config.ConsumerConfig,
 	paymentQueue string,
+	resultTarget config.PublishTarget,
 ) *OrderConsumerHandler {
 	return &OrderConsumerHandler{
 		queueManager: queueManager,
 		logger:       logger,
-		orderQueue:   orderQueue,
+		consumer:     consumer,
+		orderQueue:   consumer.Queue,
 		paymentQueue: paymentQueue,
+		resultTarget: resultTarget,
 	}
 }
 
+// InboxQueue — очередь inbox, в которую сохраняются запросы на оплату
+func (h *OrderConsumerHandler) InboxQueue() string {
+	return h.orderQueue
+}
+
 // SetupQueue настраивает очереди orders и payments
 func (h *OrderConsumerHandler) SetupQueue(ctx context.Context) error {
 	h.logger.Info("Setting up RabbitMQ queues")
 
 	// Настраиваем очередь для приема заказов (orders)
 	orderPublisher := h.queueManager.GetOrCreatePublisher("orders", messaging.PublisherConfig{
-		Exchange:   "orders",
-		RoutingKey: h.orderQueue,
+		Exchange:   h.consumer.Exchange,
+		RoutingKey: h.consumer.RoutingKey,
 		Mandatory:  false,
 		Immediate:  false,
 	})
 
 	// Создаем exchange и очередь для orders
-	if err := orderPublisher.DeclareExchange("orders", "direct", true); err != nil {
+	if err := orderPublisher.DeclareExchange(h.consumer.Exchange, "direct", true); err != nil {
 		return err
 	}
 	if err := orderPublisher.DeclareQueue(h.orderQueue, true, false); err != nil {
 		return err
 	}
-	if err := orderPublisher.BindQueue(h.orderQueue, h.orderQueue); err != nil {
+	if err := orderPublisher.BindQueue(h.orderQueue, h.consumer.RoutingKey); err != nil {
 		return err
 	}
 
 	// Настраиваем очередь для отправки результатов платежей (payments)
 	paymentPublisher := h.queueManager.GetOrCreatePublisher("payments", messaging.PublisherConfig{
-		Exchange:   "payments",
-		RoutingKey: h.paymentQueue,
+		Exchange:   h.resultTarget.Exchange,
+		RoutingKey: h.resultTarget.RoutingKey,
 		Mandatory:  false,
 		Immediate:  false,
 	})
 
-	// Создаем exchange и очередь для payments
-	if err := paymentPublisher.DeclareExchange("payments", "direct", true); err != nil {
-		return err
-	}
+	// Создаем очередь результатов и привязываем ее к адресу, по которому outbox публикует результаты.
+	// Для exchange по умолчанию привязка не нужна: сообщение попадает в очередь с именем routing key
 	if err := paymentPublisher.DeclareQueue(h.paymentQueue, true, false); err != nil {
 		return err
 	}
-	if err := paymentPublisher.BindQueue(h.paymentQueue, h.paymentQueue); err != nil {
-		return err
+	if h.resultTarget.Exchange != "" {
+		if err := paymentPublisher.DeclareExchange(h.resultTarget.Exchange, "direct", true); err != nil {
+			return err
+		}
+		if err := paymentPublisher.BindQueue(h.paymentQueue, h.resultTarget.RoutingKey); err != nil {
+			return err
+		}
 	}
 
 	h.logger.WithFields(logrus.Fields{
@@ -94,12 +108,12 @@ func (h *OrderConsumerHandler) StartConsumer(ctx context.Context, conn *messagin
 		conn,
 		messaging.ConsumerConfig{
 			QueueName:     h.orderQueue,
-			ConsumerTag:   "payments-service",
+			ConsumerTag:   h.consumer.ConsumerTag,
 			AutoAck:       false, // Важно для transactional inbox
 			Exclusive:     false,
 			NoLocal:       false,
 			NoWait:        false,
-			PrefetchCount: 10,
+			PrefetchCount: h.consumer.Prefetch,
 		},
 		h.HandleOrderRequest(messageService),
 	)
@@ -129,12 +143,7 @@ func (h *OrderConsumerHandler) HandleOrderRequest(messageService services.Messag
 		}
 
 		// 3. Сохраняем в inbox (Transactional Inbox - часть 1)
-		if err := messageService.SaveInboxMessage(
-			ctx,
-			delivery.MessageId,
-			h.orderQueue,
-			delivery.Body,
-		); err != nil {
+		if err := messageService.SaveInboxMessage(ctx, inbox.FromDelivery(h.orderQueue, delivery)); err != nil {
 			h.logger.WithError(err).Error("Failed to save message to inbox")
 			delivery.Nack(false, true) // requeue - transient ошибка
 			return err
@@ -149,38 +158,3 @@ func (h *OrderConsumerHandler) HandleOrderRequest(messageService services.Messag
 		return nil
 	}
 }
-
-// SendPaymentResult отправляет результат платежа в очередь payments
-func (h *OrderConsumerHandler) SendPaymentResult(ctx context.Context, result services.PaymentResult) error {
-	publisher := h.queueManager.GetOrCreatePublisher("payments", messaging.PublisherConfig{
-		Exchange:   "payments",
-		RoutingKey: h.paymentQueue,
-		Mandatory:  false,
-		Immediate:  false,
-	})
-
-	payload, err := json.Marshal(result)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to marshal payment result")
-		return err
-	}
-
-	// Добавляем timestamp, если нет
-	if result.Timestamp == "" {
-		result.Timestamp = time.Now().Format(time.RFC3339)
-		payload, _ = json.Marshal(result)
-	}
-
-	if err := publisher.Publish(ctx, payload); err != nil {
-		h.logger.WithError(err).WithField("order_id", result.OrderID).Error("Failed to send payment result")
-		return err
-	}
-
-	h.logger.WithFields(logrus.Fields{
-		"order_id": result.OrderID,
-		"status":   result.Status,
-		"queue":    h.paymentQueue,
-	}).Info("Payment result sent")
-
-	return nil
-}
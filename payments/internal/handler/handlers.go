@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	payments "sd_hw4/payments/internal/gen"
 	"sd_hw4/payments/internal/services"
+	"sd_hw4/pkg/money"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -49,18 +52,23 @@ func (h *Handler) PostAddBillId(c echo.Context, billID string, params payments.P
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user_id format"})
 	}
 
-	var request struct {
-		Amount float64 `json:"amount"`
+	// Тело запроса — сумма пополнения: {"amount": "100.50", "currency": "RUB"}
+	var amount money.Money
+	if err := json.NewDecoder(c.Request().Body).Decode(&amount); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid amount: " + err.Error()})
 	}
-	if err := c.Bind(&request); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	if !amount.IsPositive() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "amount must be positive"})
 	}
 
-	bill, err := h.billService.UpdateBalance(c.Request().Context(), billID, userID, request.Amount)
+	bill, err := h.billService.UpdateBalance(c.Request().Context(), billID, userID, amount)
 	if err != nil {
 		if err.Error() == "bill does not belong to user" {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "bill not found for user"})
 		}
+		if errors.Is(err, money.ErrCurrencyMismatch) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
@@ -97,3 +105,16 @@ func (h *Handler) GetBalanceBillId(c echo.Context, billID string, params payment
 		"user_id": userID,
 	})
 }
+
+func (h *Handler) GetOperationsOrderId(c echo.Context, orderID string) error {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid order_id format"})
+	}
+
+	operations, err := h.paymentService.GetOperations(c.Request().Context(), orderID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, operations)
+}
@@ -3,24 +3,29 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/money"
+
 	"github.com/google/uuid"
 )
 
 type BillStatus string
 
 const (
-	BillStatusActive   BillStatus = "active"
-	BillStatusClosed   BillStatus = "closed"
+	BillStatusActive    BillStatus = "active"
+	BillStatusClosed    BillStatus = "closed"
 	BillStatusSuspended BillStatus = "suspended"
 )
 
 type Bill struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	Balance   float64
-	Currency  string
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// Balance — остаток в валюте счета
+	Balance   money.Money
 	Status    BillStatus
 	CreatedAt time.Time
 	UpdatedAt *time.Time
@@ -42,26 +47,35 @@ func (r *BillRepository) Create(ctx context.Context, bill *Bill) error {
 	query := `INSERT INTO bills (id, user_id, balance, currency, status, created_at)
 			 VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := r.db.ExecContext(ctx, query, bill.ID, bill.UserID, bill.Balance, bill.Currency, bill.Status, bill.CreatedAt)
+	_, err := r.db.ExecContext(ctx, query, bill.ID, bill.UserID, bill.Balance.Decimal(), bill.Balance.Currency(), bill.Status, bill.CreatedAt)
 	return err
 }
 
 func (r *BillRepository) GetByID(ctx context.Context, id uuid.UUID) (*Bill, error) {
-	bill := &Bill{}
 	query := `SELECT id, user_id, balance, currency, status, created_at, updated_at, closed_at
 			 FROM bills WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&bill.ID, &bill.UserID, &bill.Balance, &bill.Currency, &bill.Status, &bill.CreatedAt, &bill.UpdatedAt, &bill.ClosedAt,
-	)
-	return bill, err
+	return scanBill(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *BillRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Bill, error) {
 	query := `SELECT id, user_id, balance, currency, status, created_at, updated_at, closed_at
 			 FROM bills WHERE user_id = $1`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	return r.queryBills(ctx, r.db, query, userID)
+}
+
+// LockByUserID возвращает счета пользователя и блокирует их до конца транзакции exec,
+// чтобы параллельные списания не работали с устаревшим балансом
+func (r *BillRepository) LockByUserID(ctx context.Context, exec db.Executor, userID uuid.UUID) ([]*Bill, error) {
+	query := `SELECT id, user_id, balance, currency, status, created_at, updated_at, closed_at
+			 FROM bills WHERE user_id = $1 ORDER BY created_at FOR UPDATE`
+
+	return r.queryBills(ctx, exec, query, userID)
+}
+
+func (r *BillRepository) queryBills(ctx context.Context, exec db.Executor, query string, args ...any) ([]*Bill, error) {
+	rows, err := exec.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,8 +83,7 @@ func (r *BillRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*
 
 	var bills []*Bill
 	for rows.Next() {
-		bill := &Bill{}
-		err := rows.Scan(&bill.ID, &bill.UserID, &bill.Balance, &bill.Currency, &bill.Status, &bill.CreatedAt, &bill.UpdatedAt, &bill.ClosedAt)
+		bill, err := scanBill(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -79,17 +92,72 @@ func (r *BillRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*
 	return bills, rows.Err()
 }
 
-func (r *BillRepository) Update(ctx context.Context, bill *Bill) error {
+// scanBill читает счет из строки выборки столбцов id, user_id, balance, currency, status,
+// created_at, updated_at, closed_at
+func scanBill(row interface{ Scan(...any) error }) (*Bill, error) {
+	bill := &Bill{}
+	var balance, currency string
+	err := row.Scan(&bill.ID, &bill.UserID, &balance, &currency, &bill.Status, &bill.CreatedAt, &bill.UpdatedAt, &bill.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	if bill.Balance, err = money.ParseSigned(balance, money.Currency(currency)); err != nil {
+		return nil, fmt.Errorf("bill %s: %w", bill.ID, err)
+	}
+	return bill, nil
+}
+
+// Update сохраняет баланс и статус счета; exec позволяет выполнить обновление внутри транзакции
+func (r *BillRepository) Update(ctx context.Context, exec db.Executor, bill *Bill) error {
 	now := time.Now()
 	bill.UpdatedAt = &now
 
 	query := `UPDATE bills SET balance = $1, status = $2, updated_at = $3 WHERE id = $4`
-	_, err := r.db.ExecContext(ctx, query, bill.Balance, bill.Status, bill.UpdatedAt, bill.ID)
+	_, err := exec.ExecContext(ctx, query, bill.Balance.Decimal(), bill.Status, bill.UpdatedAt, bill.ID)
 	return err
 }
 
+// AddBalance атомарно изменяет баланс на amount и записывает в bill новое значение.
+// Сумма в другой валюте не зачисляется: возвращается money.ErrCurrencyMismatch
+func (r *BillRepository) AddBalance(ctx context.Context, bill *Bill, amount money.Money) error {
+	now := time.Now()
+	bill.UpdatedAt = &now
+
+	balance, err := r.Credit(ctx, r.db, bill.ID, amount)
+	if err != nil {
+		return err
+	}
+	bill.Balance = balance
+	return nil
+}
+
+// Credit атомарно увеличивает баланс счета id на amount и возвращает новый баланс;
+// exec позволяет выполнить зачисление внутри транзакции. Для отсутствующего счета возвращает sql.ErrNoRows,
+// для суммы в валюте, отличной от валюты счета, — money.ErrCurrencyMismatch
+func (r *BillRepository) Credit(ctx context.Context, exec db.Executor, id uuid.UUID, amount money.Money) (money.Money, error) {
+	query := `UPDATE bills SET balance = balance + $1, updated_at = $2 WHERE id = $3 AND currency = $4
+			 RETURNING balance, currency`
+
+	var balance, currency string
+	err := exec.QueryRowContext(ctx, query, amount.Decimal(), time.Now(), id, amount.Currency()).Scan(&balance, &currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Отличаем отсутствующий счет от счета в другой валюте
+		var exists bool
+		if err := exec.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM bills WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return money.Money{}, err
+		}
+		if exists {
+			return money.Money{}, fmt.Errorf("%w: bill %s is not in %s", money.ErrCurrencyMismatch, id, amount.Currency())
+		}
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	return money.ParseSigned(balance, money.Currency(currency))
+}
+
 func (r *BillRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM bills WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, id)
 	return err
-}
\ No newline at end of file
+}
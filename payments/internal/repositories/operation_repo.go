@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sd_hw4/pkg/contracts"
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type OperationKind = contracts.OperationKind
+
+const (
+	OperationDebit  = contracts.OperationDebit
+	OperationRefund = contracts.OperationRefund
+)
+
+// PaymentOperation — запись журнала операций по оплате заказа. Для каждого заказа хранится
+// не больше одной операции каждого вида, в том числе неудачной: ее результат окончателен
+type PaymentOperation struct {
+	ID      uuid.UUID
+	OrderID string
+	Kind    OperationKind
+	UserID  string
+	// BillID пуст, если подходящий счет не найден
+	BillID *uuid.UUID
+	Amount money.Money
+	Status contracts.PaymentStatus
+	// ReasonCode и Reason — причина отказа; у возврата без оплаты — ReasonNotPaid
+	ReasonCode contracts.ReasonCode
+	Reason     string
+	CreatedAt  time.Time
+}
+
+// OperationRepository работает с таблицей payment_operations. Все методы принимают exec,
+// потому что операция записывается в транзакции вместе с изменением баланса
+type OperationRepository struct{}
+
+func NewOperationRepository() *OperationRepository {
+	return &OperationRepository{}
+}
+
+// Find возвращает операцию вида kind по заказу или sql.ErrNoRows, если ее нет
+func (r *OperationRepository) Find(ctx context.Context, exec db.Executor, orderID string, kind OperationKind) (*PaymentOperation, error) {
+	query := `SELECT id, order_id, kind, user_id, bill_id, amount, currency, status, reason_code, reason, created_at
+			 FROM payment_operations WHERE order_id = $1 AND kind = $2`
+
+	return scanOperation(exec.QueryRowContext(ctx, query, orderID, kind))
+}
+
+// ListByOrder возвращает операции по заказу в порядке записи
+func (r *OperationRepository) ListByOrder(ctx context.Context, exec db.Executor, orderID string) ([]*PaymentOperation, error) {
+	query := `SELECT id, order_id, kind, user_id, bill_id, amount, currency, status, reason_code, reason, created_at
+			 FROM payment_operations WHERE order_id = $1 ORDER BY created_at`
+
+	rows, err := exec.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ops []*PaymentOperation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// scanOperation читает операцию из строки выборки столбцов id, order_id, kind, user_id, bill_id,
+// amount, currency, status, reason_code, reason, created_at
+func scanOperation(row interface{ Scan(...any) error }) (*PaymentOperation, error) {
+	op := &PaymentOperation{}
+	var amount, currency string
+	err := row.Scan(&op.ID, &op.OrderID, &op.Kind, &op.UserID, &op.BillID, &amount, &currency, &op.Status, &op.ReasonCode, &op.Reason, &op.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if op.Amount, err = money.ParseSigned(amount, money.Currency(currency)); err != nil {
+		return nil, fmt.Errorf("payment operation %s: %w", op.ID, err)
+	}
+	return op, nil
+}
+
+// Create записывает операцию. Вторая операция того же вида по заказу нарушает уникальный индекс,
+// и транзакция откатывается: параллельная обработка того же запроса не пройдет дважды
+func (r *OperationRepository) Create(ctx context.Context, exec db.Executor, op *PaymentOperation) error {
+	op.ID = uuid.New()
+	op.CreatedAt = time.Now()
+
+	query := `INSERT INTO payment_operations (id, order_id, kind, user_id, bill_id, amount, currency, status, reason_code, reason, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := exec.ExecContext(ctx, query,
+		op.ID, op.OrderID, op.Kind, op.UserID, op.BillID, op.Amount.Decimal(), op.Amount.Currency(), op.Status, op.ReasonCode, op.Reason, op.CreatedAt)
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"sd_hw4/payments/internal/repositories"
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/money"
 
 	"github.com/google/uuid"
 )
@@ -13,8 +15,14 @@ type BillService interface {
 	CreateBill(ctx context.Context, userID string) (*repositories.Bill, error)
 	GetBill(ctx context.Context, billID string) (*repositories.Bill, error)
 	GetBillsByUserID(ctx context.Context, userID string) ([]*repositories.Bill, error)
-	UpdateBalance(ctx context.Context, billID, userID string, amount float64) (*repositories.Bill, error)
-	UpdateBill(ctx context.Context, bill *repositories.Bill) error
+	// UpdateBalance зачисляет amount на счет пользователя; сумма должна быть в валюте счета
+	// (иначе money.ErrCurrencyMismatch).
+	UpdateBalance(ctx context.Context, billID, userID string, amount money.Money) (*repositories.Bill, error)
+	// LockBillsByUserID возвращает счета пользователя, заблокированные до конца транзакции exec.
+	LockBillsByUserID(ctx context.Context, exec db.Executor, userID string) ([]*repositories.Bill, error)
+	UpdateBill(ctx context.Context, exec db.Executor, bill *repositories.Bill) error
+	// CreditBill зачисляет amount на счет в транзакции exec и возвращает новый баланс.
+	CreditBill(ctx context.Context, exec db.Executor, billID uuid.UUID, amount money.Money) (money.Money, error)
 }
 
 type billService struct {
@@ -32,10 +40,9 @@ func (s *billService) CreateBill(ctx context.Context, userID string) (*repositor
 	}
 
 	bill := &repositories.Bill{
-		UserID:   userUUID,
-		Balance:  0,
-		Currency: "RUB",
-		Status:   repositories.BillStatusActive,
+		UserID:  userUUID,
+		Balance: money.Zero(money.DefaultCurrency),
+		Status:  repositories.BillStatusActive,
 	}
 
 	err = s.billRepo.Create(ctx, bill)
@@ -64,7 +71,7 @@ func (s *billService) GetBillsByUserID(ctx context.Context, userID string) ([]*r
 	return s.billRepo.GetByUserID(ctx, userUUID)
 }
 
-func (s *billService) UpdateBalance(ctx context.Context, billID, userID string, amount float64) (*repositories.Bill, error) {
+func (s *billService) UpdateBalance(ctx context.Context, billID, userID string, amount money.Money) (*repositories.Bill, error) {
 	billUUID, err := uuid.Parse(billID)
 	if err != nil {
 		return nil, err
@@ -80,8 +87,8 @@ func (s *billService) UpdateBalance(ctx context.Context, billID, userID string,
 		return nil, fmt.Errorf("bill does not belong to user")
 	}
 
-	bill.Balance += amount
-	err = s.billRepo.Update(ctx, bill)
+	// Пополнение не перезаписывает баланс целиком и не теряет параллельное списание
+	err = s.billRepo.AddBalance(ctx, bill, amount)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +96,19 @@ func (s *billService) UpdateBalance(ctx context.Context, billID, userID string,
 	return bill, nil
 }
 
-func (s *billService) UpdateBill(ctx context.Context, bill *repositories.Bill) error {
-	return s.billRepo.Update(ctx, bill)
+func (s *billService) LockBillsByUserID(ctx context.Context, exec db.Executor, userID string) ([]*repositories.Bill, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.billRepo.LockByUserID(ctx, exec, userUUID)
+}
+
+func (s *billService) UpdateBill(ctx context.Context, exec db.Executor, bill *repositories.Bill) error {
+	return s.billRepo.Update(ctx, exec, bill)
+}
+
+func (s *billService) CreditBill(ctx context.Context, exec db.Executor, billID uuid.UUID, amount money.Money) (money.Money, error) {
+	return s.billRepo.Credit(ctx, exec, billID, amount)
 }
@@ -3,65 +3,42 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
 	"log"
-	"time"
+
+	"sd_hw4/pkg/contracts"
 )
 
+// PaymentProcessor обрабатывает запросы на оплату из inbox (см. inbox.Dispatcher)
 type PaymentProcessor struct {
 	paymentService PaymentService
-	messageService MessageService
 }
 
-func NewPaymentProcessor(paymentService PaymentService, messageService MessageService) *PaymentProcessor {
-	return &PaymentProcessor{
-		paymentService: paymentService,
-		messageService: messageService,
-	}
+func NewPaymentProcessor(paymentService PaymentService) *PaymentProcessor {
+	return &PaymentProcessor{paymentService: paymentService}
 }
 
-func (p *PaymentProcessor) ProcessMessages(ctx context.Context) {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			p.processBatch(ctx)
-		}
+// HandlePaymentRequest списывает оплату в транзакции, в которой сообщение отмечается обработанным:
+// при сбое откатываются и списание, и отметка. Повторная обработка уже оплаченного заказа (replay)
+// находит операцию в журнале payment_operations и только повторяет результат
+func (p *PaymentProcessor) HandlePaymentRequest(ctx context.Context, tx *sql.Tx, request contracts.PaymentRequested) error {
+	result, err := p.paymentService.ProcessPayment(ctx, tx, request)
+	if err != nil {
+		return err
 	}
+
+	log.Printf("Payment processed: OrderID=%s, Status=%s", result.OrderID, result.Status)
+	return nil
 }
 
-func (p *PaymentProcessor) processBatch(ctx context.Context) {
-	// Получаем непрочитанные сообщения из inbox
-	messages, err := p.messageService.GetUnprocessedMessages(ctx, "payments.payment_requests", 10)
+// HandleRefundRequest возвращает оплату отмененного заказа в транзакции обработки сообщения;
+// повторная обработка находит операцию возврата в журнале и только повторяет результат
+func (p *PaymentProcessor) HandleRefundRequest(ctx context.Context, tx *sql.Tx, request contracts.RefundRequested) error {
+	result, err := p.paymentService.ProcessRefund(ctx, tx, request)
 	if err != nil {
-		log.Printf("Error getting unprocessed messages: %v", err)
-		return
+		return err
 	}
 
-	for _, msg := range messages {
-		var request PaymentRequest
-		if err := json.Unmarshal(msg.Payload, &request); err != nil {
-			log.Printf("Error unmarshaling payment request: %v", err)
-			p.messageService.MarkMessageProcessed(ctx, msg.MessageID)
-			continue
-		}
-
-		// Обрабатываем платеж
-		result, err := p.paymentService.ProcessPayment(ctx, request)
-		if err != nil {
-			log.Printf("Error processing payment: %v", err)
-			// Можно добавить retry логику здесь
-		}
-
-		log.Printf("Payment processed: OrderID=%s, Status=%s", result.OrderID, result.Status)
-
-		// Помечаем сообщение как обработанное
-		if err := p.messageService.MarkMessageProcessed(ctx, msg.MessageID); err != nil {
-			log.Printf("Error marking message as processed: %v", err)
-		}
-	}
+	log.Printf("Refund processed: OrderID=%s, Status=%s, Amount=%s", result.OrderID, result.Status, result.Amount)
+	return nil
 }
@@ -2,65 +2,157 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"sd_hw4/payments/internal/repositories"
+	"sd_hw4/pkg/config"
+	"sd_hw4/pkg/contracts"
+	"sd_hw4/pkg/inbox"
+	"sd_hw4/pkg/money"
+	"sd_hw4/pkg/outbox"
 
 	"github.com/google/uuid"
 )
 
-type PaymentRequest struct {
-	OrderID   string  `json:"order_id"`
-	UserID    string  `json:"user_id"`
-	Amount    float64 `json:"amount"`
-	Timestamp string  `json:"timestamp"`
-}
-
-type PaymentResult struct {
-	OrderID   string `json:"order_id"`
-	UserID    string `json:"user_id"`
-	Status    string `json:"status"` // "success" или "failed"
-	Reason    string `json:"reason,omitempty"`
-	Timestamp string `json:"timestamp"`
-}
-
 type PaymentService interface {
-	ProcessPayment(ctx context.Context, request PaymentRequest) (*PaymentResult, error)
+	// ProcessPayment списывает оплату заказа и записывает результат в outbox в транзакции tx.
+	// Отказ (нет счета, не хватает средств) — тоже результат; ошибка возвращается только
+	// при сбое, после которого запрос нужно обработать повторно. Повторный запрос по тому же
+	// заказу не списывает деньги: записанный результат отправляется еще раз.
+	ProcessPayment(ctx context.Context, tx *sql.Tx, request contracts.PaymentRequested) (*contracts.PaymentResult, error)
+	// ProcessRefund возвращает оплату отмененного заказа на счет и записывает результат в outbox
+	// в транзакции tx. Если заказ еще не оплачен, последующее списание по нему запрещается.
+	// Повторный запрос не зачисляет деньги второй раз: записанный результат отправляется еще раз.
+	ProcessRefund(ctx context.Context, tx *sql.Tx, request contracts.RefundRequested) (*contracts.RefundResult, error)
+	// GetOperations возвращает записанные операции по заказу: по ним отправитель запроса
+	// узнает итог оплаты, если результат не дошел. Пустой список — запросов по заказу еще не было.
+	GetOperations(ctx context.Context, orderID string) ([]contracts.PaymentOperation, error)
 }
 
 type paymentService struct {
-	billService    BillService
-	messageService MessageService
+	db           *sql.DB
+	billService  BillService
+	operations   *repositories.OperationRepository
+	outboxStore  outbox.Store
+	resultTarget config.PublishTarget
 }
 
-func NewPaymentService(billService BillService, messageService MessageService) PaymentService {
+func NewPaymentService(conn *sql.DB, billService BillService, operations *repositories.OperationRepository, outboxStore outbox.Store, resultTarget config.PublishTarget) PaymentService {
 	return &paymentService{
-		billService:    billService,
-		messageService: messageService,
+		db:           conn,
+		billService:  billService,
+		operations:   operations,
+		outboxStore:  outboxStore,
+		resultTarget: resultTarget,
 	}
 }
 
-func (s *paymentService) ProcessPayment(ctx context.Context, request PaymentRequest) (*PaymentResult, error) {
-	result := &PaymentResult{
+func (s *paymentService) ProcessPayment(ctx context.Context, tx *sql.Tx, request contracts.PaymentRequested) (*contracts.PaymentResult, error) {
+	result := &contracts.PaymentResult{
 		OrderID:   request.OrderID,
 		UserID:    request.UserID,
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	// Получаем счет пользователя
-	bills, err := s.billService.GetBillsByUserID(ctx, request.UserID)
+	op, err := s.operations.Find(ctx, tx, request.OrderID, repositories.OperationDebit)
+	switch {
+	case err == nil:
+		// Повторная доставка или replay: результат уже определен
+		inbox.Effect(ctx, "payment for order %s is already recorded with status %s, no debit", request.OrderID, op.Status)
+	case errors.Is(err, sql.ErrNoRows):
+		if op, err = s.debit(ctx, tx, request); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("error fetching payment operation: %w", err)
+	}
+	result.Status = op.Status
+	result.ReasonCode = op.ReasonCode
+	result.Reason = op.Reason
+
+	// Результат записывается в outbox в той же транзакции, что и списание
+	outboxMsg, err := outbox.NewMessage(s.resultTarget.Exchange, s.resultTarget.RoutingKey, contracts.TypePaymentResult, result)
+	if err != nil {
+		return nil, err
+	}
+	// Результаты по одному заказу публикуются в порядке записи
+	if err := s.outboxStore.Add(ctx, tx, outboxMsg.ForAggregate(request.OrderID)); err != nil {
+		return nil, fmt.Errorf("failed to save outbox message: %w", err)
+	}
+	inbox.Effect(ctx, "send %s %s for order %s to %s/%s", contracts.TypePaymentResult, result.Status, request.OrderID,
+		s.resultTarget.Exchange, s.resultTarget.RoutingKey)
+
+	return result, nil
+}
+
+// debit списывает сумму с активного счета пользователя и записывает операцию в журнал.
+// Отказ записывается как операция со статусом PaymentFailed и причиной.
+func (s *paymentService) debit(ctx context.Context, tx *sql.Tx, request contracts.PaymentRequested) (*repositories.PaymentOperation, error) {
+	op := &repositories.PaymentOperation{
+		OrderID: request.OrderID,
+		Kind:    repositories.OperationDebit,
+		UserID:  request.UserID,
+		Amount:  request.Amount,
+		Status:  contracts.PaymentSucceeded,
+	}
+
+	bill, reason, err := s.chargeableBill(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+	if bill != nil {
+		op.BillID = &bill.ID
+	}
+
+	if reason != "" {
+		op.Status = contracts.PaymentFailed
+		op.ReasonCode = reason
+		op.Reason = reason.Message()
+		inbox.Effect(ctx, "reject payment for order %s: %s", request.OrderID, reason)
+	} else {
+		// Списание средств; валюта и достаточность баланса проверены в chargeableBill
+		balance := bill.Balance
+		if bill.Balance, err = balance.Sub(request.Amount); err != nil {
+			return nil, fmt.Errorf("failed to debit bill: %w", err)
+		}
+		if err := s.billService.UpdateBill(ctx, tx, bill); err != nil {
+			return nil, fmt.Errorf("failed to update balance: %w", err)
+		}
+		inbox.Effect(ctx, "debit %s from bill %s for order %s, balance %s -> %s",
+			request.Amount, bill.ID, request.OrderID, balance, bill.Balance)
+	}
+
+	if err := s.operations.Create(ctx, tx, op); err != nil {
+		return nil, fmt.Errorf("failed to record payment operation: %w", err)
+	}
+	return op, nil
+}
+
+// chargeableBill находит активный счет пользователя с достаточным балансом. Возвращает код
+// причины отказа, если списать нельзя; счет возвращается, если он найден, даже при отказе.
+func (s *paymentService) chargeableBill(ctx context.Context, tx *sql.Tx, request contracts.PaymentRequested) (*repositories.Bill, contracts.ReasonCode, error) {
+	// Некорректный запрос не станет корректным при повторе
+	if _, err := uuid.Parse(request.UserID); err != nil {
+		inbox.Effect(ctx, "invalid user id %q", request.UserID)
+		return nil, contracts.ReasonInvalidRequest, nil
+	}
+	// Сумма без валюты — в запросе нет amount
+	if request.Amount.Currency() == "" || request.Amount.IsNegative() {
+		inbox.Effect(ctx, "invalid amount %s", request.Amount)
+		return nil, contracts.ReasonInvalidRequest, nil
+	}
+
+	// Счета блокируются до конца транзакции
+	bills, err := s.billService.LockBillsByUserID(ctx, tx, request.UserID)
 	if err != nil {
-		result.Status = "failed"
-		result.Reason = "error fetching bills"
-		return result, err
+		return nil, "", fmt.Errorf("error fetching bills: %w", err)
 	}
 
 	if len(bills) == 0 {
-		result.Status = "failed"
-		result.Reason = "no bill found for user"
-		return result, nil
+		return nil, contracts.ReasonNoBill, nil
 	}
 
 	// Используем первый активный счет
@@ -73,45 +165,135 @@ func (s *paymentService) ProcessPayment(ctx context.Context, request PaymentRequ
 	}
 
 	if activeBill == nil {
-		result.Status = "failed"
-		result.Reason = "no active bill found"
-		return result, nil
+		return nil, contracts.ReasonNoActiveBill, nil
 	}
 
-	// Проверяем достаточно ли средств
-	if activeBill.Balance < request.Amount {
-		result.Status = "failed"
-		result.Reason = "insufficient funds"
-		return result, nil
+	// Проверяем валюту и достаточность средств
+	cmp, err := activeBill.Balance.Compare(request.Amount)
+	if errors.Is(err, money.ErrCurrencyMismatch) {
+		return activeBill, contracts.ReasonCurrencyMismatch, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if cmp < 0 {
+		return activeBill, contracts.ReasonInsufficientFunds, nil
 	}
+	return activeBill, "", nil
+}
 
-	// Списание средств
-	activeBill.Balance -= request.Amount
-	err = s.billService.UpdateBill(ctx, activeBill)
+func (s *paymentService) ProcessRefund(ctx context.Context, tx *sql.Tx, request contracts.RefundRequested) (*contracts.RefundResult, error) {
+	result := &contracts.RefundResult{
+		OrderID:   request.OrderID,
+		UserID:    request.UserID,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	op, err := s.operations.Find(ctx, tx, request.OrderID, repositories.OperationRefund)
+	switch {
+	case err == nil:
+		inbox.Effect(ctx, "refund for order %s is already recorded with status %s, no credit", request.OrderID, op.Status)
+	case errors.Is(err, sql.ErrNoRows):
+		if op, err = s.refund(ctx, tx, request); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("error fetching refund operation: %w", err)
+	}
+	result.Status = op.Status
+	result.Amount = op.Amount
+	result.ReasonCode = op.ReasonCode
+	result.Reason = op.Reason
+
+	outboxMsg, err := outbox.NewMessage(s.resultTarget.Exchange, s.resultTarget.RoutingKey, contracts.TypeRefundResult, result)
 	if err != nil {
-		result.Status = "failed"
-		result.Reason = "failed to update balance"
-		return result, err
+		return nil, err
+	}
+	if err := s.outboxStore.Add(ctx, tx, outboxMsg.ForAggregate(request.OrderID)); err != nil {
+		return nil, fmt.Errorf("failed to save outbox message: %w", err)
 	}
+	inbox.Effect(ctx, "send %s %s for order %s to %s/%s", contracts.TypeRefundResult, result.Status, request.OrderID,
+		s.resultTarget.Exchange, s.resultTarget.RoutingKey)
 
-	result.Status = "success"
+	return result, nil
+}
+
+// refund возвращает сумму успешного списания по заказу на тот же счет и записывает операцию
+// в журнал. Если списания еще не было, записывает отказ в списании: запрос на оплату,
+// который придет позже, получит его как результат и не спишет деньги.
+func (s *paymentService) refund(ctx context.Context, tx *sql.Tx, request contracts.RefundRequested) (*repositories.PaymentOperation, error) {
+	// Возвращать нечего, пока не найдено успешное списание
+	op := &repositories.PaymentOperation{
+		OrderID: request.OrderID,
+		Kind:    repositories.OperationRefund,
+		UserID:  request.UserID,
+		Amount:  money.Zero(money.DefaultCurrency),
+		Status:  contracts.PaymentSucceeded,
+	}
+
+	debit, err := s.operations.Find(ctx, tx, request.OrderID, repositories.OperationDebit)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		blocked := &repositories.PaymentOperation{
+			OrderID:    request.OrderID,
+			Kind:       repositories.OperationDebit,
+			UserID:     request.UserID,
+			Amount:     money.Zero(money.DefaultCurrency),
+			Status:     contracts.PaymentFailed,
+			ReasonCode: contracts.ReasonOrderCanceled,
+			Reason:     contracts.ReasonOrderCanceled.Message(),
+		}
+		if err := s.operations.Create(ctx, tx, blocked); err != nil {
+			return nil, fmt.Errorf("failed to record payment operation: %w", err)
+		}
+		inbox.Effect(ctx, "order %s was not paid, block later debit", request.OrderID)
+		op.ReasonCode, op.Reason = contracts.ReasonNotPaid, contracts.ReasonNotPaid.Message()
+	case err != nil:
+		return nil, fmt.Errorf("error fetching payment operation: %w", err)
+	case debit.Status != contracts.PaymentSucceeded || debit.BillID == nil:
+		inbox.Effect(ctx, "order %s was not paid, nothing to refund", request.OrderID)
+		op.ReasonCode, op.Reason = contracts.ReasonNotPaid, contracts.ReasonNotPaid.Message()
+	default:
+		op.BillID = debit.BillID
+		op.Amount = debit.Amount
+
+		balance, err := s.billService.CreditBill(ctx, tx, *debit.BillID, debit.Amount)
+		if errors.Is(err, sql.ErrNoRows) {
+			op.Status = contracts.PaymentFailed
+			op.ReasonCode, op.Reason = contracts.ReasonBillNotFound, contracts.ReasonBillNotFound.Message()
+			inbox.Effect(ctx, "reject refund for order %s: bill %s not found", request.OrderID, debit.BillID)
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to credit bill: %w", err)
+		}
+		inbox.Effect(ctx, "credit %s to bill %s for order %s, balance -> %s",
+			debit.Amount, debit.BillID, request.OrderID, balance)
+	}
 
-	// Сохраняем задачу на отправку результата в outbox
-	payload, _ := json.Marshal(result)
-	outboxMsg := &repositories.OutboxMessage{
-		MessageID:  uuid.New().String(),
-		Exchange:   "",
-		RoutingKey: "payments.result",
-		Payload:    payload,
-		Status:     repositories.StatusPending,
-		RetryCount: 0,
+	if err := s.operations.Create(ctx, tx, op); err != nil {
+		return nil, fmt.Errorf("failed to record refund operation: %w", err)
 	}
+	return op, nil
+}
 
-	err = s.messageService.SaveOutboxMessage(ctx, outboxMsg)
+func (s *paymentService) GetOperations(ctx context.Context, orderID string) ([]contracts.PaymentOperation, error) {
+	ops, err := s.operations.ListByOrder(ctx, s.db, orderID)
 	if err != nil {
-		// Логируем ошибку, но не возвращаем ее, так как платеж уже выполнен
-		fmt.Printf("Failed to save outbox message: %v\n", err)
+		return nil, fmt.Errorf("error fetching payment operations: %w", err)
 	}
 
+	result := make([]contracts.PaymentOperation, len(ops))
+	for i, op := range ops {
+		result[i] = contracts.PaymentOperation{
+			OrderID:    op.OrderID,
+			Kind:       op.Kind,
+			Status:     op.Status,
+			Amount:     op.Amount,
+			ReasonCode: op.ReasonCode,
+			Reason:     op.Reason,
+			Timestamp:  op.CreatedAt.Format(time.RFC3339),
+		}
+	}
 	return result, nil
 }
@@ -0,0 +1,12 @@
+-- Журнал действий операторов через административный API
+CREATE TABLE IF NOT EXISTS "admin_audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar(100) NOT NULL,
+  "action" varchar(100) NOT NULL,
+  "target" varchar(100) NOT NULL,
+  "target_id" varchar(100) NOT NULL,
+  "note" text NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS "admin_audit_log_target_idx" ON "admin_audit_log" ("target", "target_id");
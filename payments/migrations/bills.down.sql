@@ -1 +1,2 @@
+DROP TABLE IF EXISTS payment_operations ;
 DROP TABLE IF EXISTS bills ;
\ No newline at end of file
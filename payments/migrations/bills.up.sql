@@ -7,4 +7,40 @@ CREATE TABLE IF NOT EXISTS "bills" (
   "created_at" timestamp DEFAULT (now()),
   "updated_at" timestamp,
   "closed_at" timestamp
-);
\ No newline at end of file
+);
+
+-- Журнал операций по оплате заказов: не больше одной операции каждого вида на заказ.
+-- Повторная обработка запроса на оплату (повторная доставка, replay через административный API)
+-- находит записанную операцию и повторяет ее результат, не списывая деньги второй раз
+CREATE TABLE IF NOT EXISTS "payment_operations" (
+  "id" uuid PRIMARY KEY,
+  "order_id" varchar(100) NOT NULL,
+  "kind" varchar(20) NOT NULL,
+  "user_id" varchar(100) NOT NULL,
+  "bill_id" uuid REFERENCES "bills" ("id"),
+  "amount" decimal(15,2) NOT NULL,
+  "status" varchar(20) NOT NULL,
+  "reason" text NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  UNIQUE ("order_id", "kind")
+);
+
+-- Валюта суммы операции: сумма хранится в decimal и читается вместе с валютой (см. pkg/money)
+ALTER TABLE "payment_operations" ADD COLUMN IF NOT EXISTS "currency" varchar(3) NOT NULL DEFAULT 'RUB';
+
+-- Код причины отказа (contracts.ReasonCode) рядом с текстом. Операциям, записанным раньше,
+-- код подбирается по тексту причины
+ALTER TABLE "payment_operations" ADD COLUMN IF NOT EXISTS "reason_code" varchar(50) NOT NULL DEFAULT '';
+UPDATE "payment_operations" SET "reason_code" = CASE "reason"
+    WHEN 'insufficient funds' THEN 'insufficient_funds'
+    WHEN 'no bill found for user' THEN 'no_bill'
+    WHEN 'no active bill found' THEN 'no_active_bill'
+    WHEN 'invalid user id' THEN 'invalid_request'
+    WHEN 'invalid amount' THEN 'invalid_request'
+    WHEN 'currency mismatch' THEN 'currency_mismatch'
+    WHEN 'order canceled' THEN 'order_canceled'
+    WHEN 'order was not paid' THEN 'not_paid'
+    WHEN 'bill not found' THEN 'bill_not_found'
+    ELSE 'unknown'
+  END
+WHERE "reason_code" = '' AND "reason" <> '';
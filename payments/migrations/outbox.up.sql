@@ -19,3 +19,56 @@ CREATE INDEX ON "outbox_messages" ("status", "created_at");
 CREATE INDEX ON "outbox_messages" ("exchange");
 
 CREATE INDEX ON "outbox_messages" ("created_at");
+
+-- Несколько экземпляров relay захватывают пачки строк: строка принадлежит
+-- claimed_by до lease_until, после истечения аренды ее может забрать другой экземпляр
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "claimed_by" varchar(100);
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "lease_until" timestamp;
+
+-- Неудачная публикация повторяется не раньше next_attempt_at (экспоненциальная задержка),
+-- после исчерпания попыток строка переходит в конечный статус 'dead'
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "next_attempt_at" timestamp NOT NULL DEFAULT (now());
+
+DROP INDEX IF EXISTS "outbox_messages_pending_idx";
+CREATE INDEX IF NOT EXISTS "outbox_messages_due_idx" ON "outbox_messages" ("next_attempt_at") WHERE "status" IN ('pending', 'failed');
+CREATE INDEX IF NOT EXISTS "outbox_messages_dead_idx" ON "outbox_messages" ("created_at") WHERE "status" = 'dead';
+
+-- Уведомление о новых строках: relay слушает канал outbox_messages и забирает
+-- сообщения сразу, не дожидаясь очередного опроса. Уведомление доставляется после фиксации транзакции.
+CREATE OR REPLACE FUNCTION "notify_outbox_messages"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('outbox_messages', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "outbox_messages_notify" ON "outbox_messages";
+CREATE TRIGGER "outbox_messages_notify" AFTER INSERT ON "outbox_messages"
+  FOR EACH STATEMENT EXECUTE FUNCTION "notify_outbox_messages"();
+
+-- Упорядоченная доставка: сообщения с одним aggregate_id (например, id заказа) публикуются
+-- строго по seq, следующее — только после отправки предыдущих. Сообщения без aggregate_id не упорядочиваются.
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "aggregate_id" varchar(100);
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "seq" bigserial;
+
+CREATE INDEX IF NOT EXISTS "outbox_messages_aggregate_idx" ON "outbox_messages" ("aggregate_id", "seq")
+  WHERE "aggregate_id" IS NOT NULL AND "status" IN ('pending', 'failed', 'dead');
+
+-- Режим outbox.mode = cdc: relay читает вставки из WAL через слот логической репликации
+-- (нужен wal_level=logical) и хранит подтвержденную позицию слота в outbox_cdc_offsets
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = 'outbox_publication') THEN
+    CREATE PUBLICATION "outbox_publication" FOR TABLE "outbox_messages" WITH (publish = 'insert, update');
+  END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS "outbox_cdc_offsets" (
+  "slot_name" varchar(64) PRIMARY KEY,
+  "lsn" pg_lsn NOT NULL,
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+-- Тип сообщения (pkg/contracts) публикуется в свойстве AMQP Type, по нему получатель выбирает обработчик
+ALTER TABLE "outbox_messages" ADD COLUMN IF NOT EXISTS "message_type" varchar(100);
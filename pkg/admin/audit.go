@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"sd_hw4/pkg/db"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Entry — запись журнала действий оператора.
+type Entry struct {
+	ID        int64     `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	TargetID  string    `json:"target_id"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLog — журнал действий операторов в таблице admin_audit_log.
+type AuditLog struct {
+	db *sql.DB
+}
+
+func NewAuditLog(db *sql.DB) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+// Do выполняет fn и записывает entry в журнал в одной транзакции:
+// действие без записи в журнале (и наоборот) не зафиксируется.
+func (l *AuditLog) Do(ctx context.Context, entry Entry, fn func(tx *sql.Tx) error) error {
+	return db.WithTx(ctx, l.db, func(tx *sql.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return l.Record(ctx, tx, entry)
+	})
+}
+
+// Record записывает entry в журнал через exec. Нужен, когда одно действие оператора
+// затрагивает несколько объектов: каждый из них получает свою запись в транзакции Do.
+func (l *AuditLog) Record(ctx context.Context, exec db.Executor, entry Entry) error {
+	query := `INSERT INTO admin_audit_log (actor, action, target, target_id, note)
+		VALUES ($1, $2, $3, $4, $5)`
+	_, err := exec.ExecContext(ctx, query, entry.Actor, entry.Action, entry.Target, entry.TargetID, entry.Note)
+	return err
+}
+
+// List возвращает до limit последних записей журнала.
+func (l *AuditLog) List(ctx context.Context, limit int) ([]Entry, error) {
+	query := `SELECT id, actor, action, target, target_id, note, created_at
+		FROM admin_audit_log ORDER BY id DESC LIMIT $1`
+
+	rows, err := l.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Target, &entry.TargetID, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// RegisterRoutes регистрирует маршрут журнала в группе (обычно /admin):
+//
+//	GET /audit?limit=100 — последние действия операторов
+func (l *AuditLog) RegisterRoutes(g *echo.Group) {
+	g.GET("/audit", l.handleList)
+}
+
+func (l *AuditLog) handleList(c echo.Context) error {
+	limit, err := Limit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	entries, err := l.List(c.Request().Context(), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list audit log"})
+	}
+	return c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,39 @@
+// Package admin — общие части административного API: проверка токена,
+// журнал действий операторов и разбор параметров запросов.
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ActorHeader — заголовок, которым оператор может представиться; значение попадает в журнал.
+const ActorHeader = "X-Admin-Actor"
+
+// RequireToken пропускает только запросы с заголовком "Authorization: Bearer <token>".
+// Токен сравнивается за постоянное время, чтобы его нельзя было подобрать по времени ответа.
+func RequireToken(token string) echo.MiddlewareFunc {
+	expected := []byte(token)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			provided, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), expected) != 1 {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="admin"`)
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or missing admin token"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// Actor возвращает имя оператора из заголовка X-Admin-Actor или "admin", если он не передан.
+func Actor(c echo.Context) string {
+	if actor := strings.TrimSpace(c.Request().Header.Get(ActorHeader)); actor != "" {
+		return actor
+	}
+	return "admin"
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Limit разбирает параметр limit (по умолчанию 100, не больше 1000).
+func Limit(c echo.Context) (int, error) {
+	raw := c.QueryParam("limit")
+	if raw == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		return 0, errors.New("limit must be between 1 and 1000")
+	}
+	return limit, nil
+}
+
+// Age разбирает параметр-длительность вроде older_than=1h; пустой параметр дает 0.
+func Age(c echo.Context, name string) (time.Duration, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	age, err := time.ParseDuration(raw)
+	if err != nil || age < 0 {
+		return 0, errors.New(name + " must be a duration like 30m or 24h")
+	}
+	return age, nil
+}
+
+// Note — тело запросов, изменяющих сообщения: пояснение оператора для журнала.
+type Note struct {
+	Note string `json:"note"`
+}
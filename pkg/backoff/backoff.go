@@ -0,0 +1,47 @@
+// Package backoff вычисляет задержки повторных попыток с экспоненциальным ростом и случайным разбросом.
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Policy — политика повторных попыток.
+type Policy struct {
+	// MaxAttempts — сколько всего попыток допускается; после последней неудачной попытки
+	// операция считается окончательно проваленной.
+	MaxAttempts int
+	// BaseDelay — задержка после первой неудачной попытки, каждая следующая удваивается.
+	BaseDelay time.Duration
+	// MaxDelay ограничивает задержку сверху.
+	MaxDelay time.Duration
+}
+
+// Exhausted сообщает, что после attempts неудачных попыток повторять больше нельзя.
+func (p Policy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// Delay возвращает задержку перед следующей попыткой после attempts неудачных (attempts >= 1).
+// Используется «equal jitter»: половина экспоненциальной задержки фиксирована, вторая половина
+// случайна, чтобы сообщения, упавшие одновременно (например, при недоступности брокера),
+// не повторялись одной волной.
+func (p Policy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
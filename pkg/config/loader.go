@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv — переменная окружения с путем к YAML-файлу конфигурации.
+// Флаг -config имеет приоритет над ней.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// fileSuffix — суффикс переменной окружения, значение которой читается из файла
+// (например, DB_CONNECTION_STRING_FILE=/run/secrets/orders_dsn).
+const fileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Validatable — конфигурация, умеющая проверять собственную корректность.
+type Validatable interface {
+	Validate() error
+}
+
+// field описывает один конечный параметр конфигурации.
+type field struct {
+	path  string // путь в YAML через точку, он же имя флага: http.port
+	env   string // имя переменной окружения: PORT или HTTP_PORT
+	value reflect.Value
+}
+
+// load накладывает слои конфигурации на cfg, в котором уже проставлены значения по умолчанию:
+// YAML-файл, затем переменные окружения (включая ссылки *_FILE), затем флаги командной строки.
+// В конце вызывается Validate.
+func load(cfg Validatable, name string, args []string) error {
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "", "")
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", os.Getenv(ConfigFileEnv), "path to YAML configuration file")
+
+	flagValues := make(map[string]*rawFlag, len(fields))
+	for _, f := range fields {
+		raw := &rawFlag{isBool: f.value.Kind() == reflect.Bool}
+		flagValues[f.path] = raw
+		fs.Var(raw, f.path, "overrides "+f.path+" (env "+f.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	if *configPath != "" {
+		if err := loadFile(cfg, *configPath); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		raw, ok, err := lookupEnv(f.env)
+		if err != nil {
+			return fmt.Errorf("config: env %s: %w", f.env+fileSuffix, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			return fmt.Errorf("config: env %s: %w", f.env, err)
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(fl *flag.Flag) {
+		raw, ok := flagValues[fl.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		for _, f := range fields {
+			if f.path == fl.Name {
+				if err := setValue(f.value, raw.value); err != nil {
+					flagErr = fmt.Errorf("config: flag -%s: %w", fl.Name, err)
+				}
+				return
+			}
+		}
+	})
+	if flagErr != nil {
+		return flagErr
+	}
+
+	return cfg.Validate()
+}
+
+// loadFile читает YAML-файл в строгом режиме: неизвестные ключи считаются ошибкой.
+func loadFile(cfg any, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: file %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookupEnv возвращает значение переменной окружения.
+// Если задана переменная NAME_FILE, значение читается из указанного файла.
+func lookupEnv(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + fileSuffix); ok && path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, err
+		}
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", false, nil
+	}
+	return value, true, nil
+}
+
+// collectFields обходит структуру конфигурации и собирает все конечные параметры.
+// Имя параметра берется из тега yaml, имя переменной окружения — из тега env
+// или выводится из пути (outbox.batch_size -> OUTBOX_BATCH_SIZE).
+func collectFields(v reflect.Value, path, envPrefix string) []field {
+	var fields []field
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, inline := yamlName(sf)
+		if name == "-" {
+			continue
+		}
+
+		fieldPath, fieldEnv := path, envPrefix
+		if !inline {
+			fieldPath = joinPath(path, name, ".")
+			fieldEnv = joinPath(envPrefix, strings.ToUpper(name), "_")
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+			fields = append(fields, collectFields(fv, fieldPath, fieldEnv)...)
+			continue
+		}
+
+		if env := sf.Tag.Get("env"); env != "" {
+			fieldEnv = env
+		}
+		fields = append(fields, field{path: fieldPath, env: fieldEnv, value: fv})
+	}
+	return fields
+}
+
+func yamlName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("yaml")
+	name, opts, _ := strings.Cut(tag, ",")
+	if strings.Contains(opts, "inline") {
+		return "", true
+	}
+	if name == "" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name, false
+}
+
+func joinPath(prefix, name, sep string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + sep + name
+}
+
+// setValue разбирает строковое значение в поле конфигурации.
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// rawFlag запоминает строковое значение флага; разбор выполняет setValue,
+// чтобы флаги и переменные окружения понимали одинаковый формат.
+type rawFlag struct {
+	value  string
+	isBool bool
+}
+
+func (f *rawFlag) String() string {
+	return f.value
+}
+
+func (f *rawFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+func (f *rawFlag) IsBoolFlag() bool {
+	return f.isBool
+}
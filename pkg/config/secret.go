@@ -0,0 +1,37 @@
+package config
+
+// redacted подставляется вместо значения секрета при любом выводе конфигурации.
+const redacted = "******"
+
+// Secret хранит чувствительное значение (пароль, DSN, токен).
+// При форматировании, логировании и сериализации значение скрывается,
+// получить его можно только явным вызовом Value.
+type Secret string
+
+// Value возвращает исходное значение секрета.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String реализует fmt.Stringer и никогда не раскрывает значение.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString скрывает значение при выводе через %#v.
+func (s Secret) GoString() string {
+	return `config.Secret("` + s.String() + `")`
+}
+
+// MarshalJSON скрывает значение при сериализации в JSON (в том числе в логах logrus).
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+// MarshalYAML скрывает значение при сериализации в YAML.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,91 @@
+// Package contracts — сообщения, которыми обмениваются сервисы: тип сообщения (AMQP Type)
+// и формат payload. Отправитель и получатель используют одни и те же структуры,
+// поэтому формат не может разойтись между сервисами.
+package contracts
+
+import "sd_hw4/pkg/money"
+
+// Типы сообщений. Получатель выбирает обработчик по очереди и типу (см. inbox.Dispatcher).
+const (
+	// TypePaymentRequested — заказ создан и ждет оплаты (orders → payments), payload PaymentRequested.
+	TypePaymentRequested = "payment.requested"
+	// TypePaymentResult — результат оплаты заказа (payments → orders), payload PaymentResult.
+	TypePaymentResult = "payment.result"
+	// TypeRefundRequested — заказ отменен, оплату нужно вернуть (orders → payments), payload RefundRequested.
+	// Отправляется в ту же очередь, что и PaymentRequested, с тем же ключом упорядочивания,
+	// поэтому обрабатывается после запроса на оплату этого заказа.
+	TypeRefundRequested = "refund.requested"
+	// TypeRefundResult — результат возврата (payments → orders), payload RefundResult.
+	TypeRefundResult = "refund.result"
+)
+
+// PaymentRequested — запрос на списание оплаты заказа.
+type PaymentRequested struct {
+	OrderID     string      `json:"order_id"`
+	UserID      string      `json:"user_id"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description,omitempty"`
+	Timestamp   string      `json:"timestamp"`
+}
+
+// PaymentStatus — итог обработки запроса на оплату.
+type PaymentStatus string
+
+const (
+	PaymentSucceeded PaymentStatus = "success"
+	PaymentFailed    PaymentStatus = "failed"
+)
+
+// PaymentResult — результат обработки PaymentRequested. Отказ (нет счета, не хватает средств)
+// тоже результат: Status = failed, код причины в ReasonCode, текст — в Reason.
+type PaymentResult struct {
+	OrderID    string        `json:"order_id"`
+	UserID     string        `json:"user_id"`
+	Status     PaymentStatus `json:"status"`
+	ReasonCode ReasonCode    `json:"reason_code,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	Timestamp  string        `json:"timestamp"`
+}
+
+// RefundRequested — запрос на возврат оплаты отмененного заказа. Если заказ еще не оплачен,
+// получатель должен запретить последующее списание по нему.
+type RefundRequested struct {
+	OrderID    string     `json:"order_id"`
+	UserID     string     `json:"user_id"`
+	ReasonCode ReasonCode `json:"reason_code,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	Timestamp  string     `json:"timestamp"`
+}
+
+// RefundResult — результат обработки RefundRequested. Amount — возвращенная сумма:
+// ноль, если заказ не был оплачен и возвращать нечего.
+type RefundResult struct {
+	OrderID    string        `json:"order_id"`
+	UserID     string        `json:"user_id"`
+	Status     PaymentStatus `json:"status"`
+	Amount     money.Money   `json:"amount"`
+	ReasonCode ReasonCode    `json:"reason_code,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	Timestamp  string        `json:"timestamp"`
+}
+
+// OperationKind — вид операции в журнале оплат payments.
+type OperationKind string
+
+const (
+	OperationDebit  OperationKind = "debit"
+	OperationRefund OperationKind = "refund"
+)
+
+// PaymentOperation — операция по заказу из журнала оплат payments (ответ GET /operations/{order_id}).
+// Записанная операция окончательна: повторный запрос на оплату или возврат по заказу получает
+// ее результат, поэтому по ней можно узнать итог оплаты, не дожидаясь сообщения.
+type PaymentOperation struct {
+	OrderID    string        `json:"order_id"`
+	Kind       OperationKind `json:"kind"`
+	Status     PaymentStatus `json:"status"`
+	Amount     money.Money   `json:"amount"`
+	ReasonCode ReasonCode    `json:"reason_code,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	Timestamp  string        `json:"timestamp"`
+}
@@ -0,0 +1,81 @@
+package contracts
+
+import "strings"
+
+// ReasonCode — стандартный код причины отказа в оплате, возврате или отмены заказа.
+// Текст причины (Reason) может уточняться, код — нет: по нему клиент решает, что показать
+// пользователю (например, предложить пополнить баланс при ReasonInsufficientFunds).
+type ReasonCode string
+
+const (
+	// ReasonInsufficientFunds — на активном счете не хватает средств.
+	ReasonInsufficientFunds ReasonCode = "insufficient_funds"
+	// ReasonNoBill — у пользователя нет счета.
+	ReasonNoBill ReasonCode = "no_bill"
+	// ReasonNoActiveBill — у пользователя нет активного счета.
+	ReasonNoActiveBill ReasonCode = "no_active_bill"
+	// ReasonCurrencyMismatch — сумма в валюте, отличной от валюты счета.
+	ReasonCurrencyMismatch ReasonCode = "currency_mismatch"
+	// ReasonInvalidRequest — некорректный запрос (id пользователя, сумма).
+	ReasonInvalidRequest ReasonCode = "invalid_request"
+	// ReasonOrderCanceled — списание запрещено: заказ отменен до оплаты.
+	ReasonOrderCanceled ReasonCode = "order_canceled"
+	// ReasonNotPaid — возвращать нечего: заказ не был оплачен.
+	ReasonNotPaid ReasonCode = "not_paid"
+	// ReasonBillNotFound — счет, с которого списана оплата, не найден при возврате.
+	ReasonBillNotFound ReasonCode = "bill_not_found"
+	// ReasonCanceledByUser — заказ отменен пользователем.
+	ReasonCanceledByUser ReasonCode = "canceled_by_user"
+	// ReasonPaymentTimeout — результат оплаты не пришел вовремя, итог в payments неизвестен.
+	ReasonPaymentTimeout ReasonCode = "payment_timeout"
+	// ReasonUnknown — причина без кода (сообщение отправителя, который кодов еще не передавал).
+	ReasonUnknown ReasonCode = "unknown"
+)
+
+// reasonMessages — текст причины по умолчанию для каждого кода.
+var reasonMessages = map[ReasonCode]string{
+	ReasonInsufficientFunds: "insufficient funds",
+	ReasonNoBill:            "no bill found for user",
+	ReasonNoActiveBill:      "no active bill found",
+	ReasonCurrencyMismatch:  "currency mismatch",
+	ReasonInvalidRequest:    "invalid request",
+	ReasonOrderCanceled:     "order canceled",
+	ReasonNotPaid:           "order was not paid",
+	ReasonBillNotFound:      "bill not found",
+	ReasonCanceledByUser:    "canceled by user",
+	ReasonPaymentTimeout:    "payment timeout",
+	ReasonUnknown:           "unknown reason",
+}
+
+// Valid сообщает, известен ли код c.
+func (c ReasonCode) Valid() bool {
+	_, ok := reasonMessages[c]
+	return ok
+}
+
+// Message возвращает текст причины по умолчанию.
+func (c ReasonCode) Message() string {
+	if message, ok := reasonMessages[c]; ok {
+		return message
+	}
+	return reasonMessages[ReasonUnknown]
+}
+
+// ReasonCodeOf возвращает код для текста причины из сообщения без кода: так отправители
+// описывали причины до появления ReasonCode. Незнакомый текст — ReasonUnknown.
+func ReasonCodeOf(message string) ReasonCode {
+	for code, text := range reasonMessages {
+		if message == text {
+			return code
+		}
+	}
+	switch {
+	case message == "invalid user id" || message == "invalid amount":
+		return ReasonInvalidRequest
+	case strings.HasPrefix(message, "canceled by user"):
+		return ReasonCanceledByUser
+	case message == "payment_timeout":
+		return ReasonPaymentTimeout
+	}
+	return ReasonUnknown
+}
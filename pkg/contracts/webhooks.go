@@ -0,0 +1,29 @@
+package contracts
+
+import "sd_hw4/pkg/money"
+
+// Типы событий вебхуков сервиса заказов (см. pkg/webhooks), payload OrderEvent.
+// Получатели — внешние системы, поэтому формат меняется только с сохранением совместимости.
+const (
+	// TypeOrderCreated — заказ создан и ждет оплаты.
+	TypeOrderCreated = "order.created"
+	// TypeOrderPaid — оплата списана, заказ в статусе finished.
+	TypeOrderPaid = "order.paid"
+	// TypeOrderCanceled — заказ отменен до оплаты: пользователем, из-за отказа в оплате или по таймауту.
+	TypeOrderCanceled = "order.canceled"
+	// TypeOrderRefunded — оплата оплаченного заказа возвращена.
+	TypeOrderRefunded = "order.refunded"
+)
+
+// OrderEvent — состояние заказа после события и причина перехода. ReasonCode заполняется
+// для отмены (order.canceled) и возврата (order.refunded): почему заказ отменен.
+type OrderEvent struct {
+	OrderID     string      `json:"order_id"`
+	UserID      string      `json:"user_id"`
+	Status      string      `json:"status"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description,omitempty"`
+	ReasonCode  ReasonCode  `json:"reason_code,omitempty"`
+	Reason      string      `json:"reason,omitempty"`
+	Timestamp   string      `json:"timestamp"`
+}
@@ -14,6 +14,14 @@ import (
 // DB wraps sql.DB for easier usage
 var DB *sql.DB
 
+// Executor is implemented by both *sql.DB and *sql.Tx, so repositories
+// can run the same statements inside or outside of a transaction.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // Connect connects to the database using a DSN
 func Connect(dsn string) error {
 	db, err := sql.Open("postgres", dsn)
@@ -156,6 +164,25 @@ func Begin() (*sql.Tx, error) {
 	return DB.Begin()
 }
 
+// WithTx runs fn inside a transaction. The transaction is committed if fn
+// returns nil and rolled back otherwise.
+func WithTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // Close closes the database connection.
 func Close() error {
 	if DB == nil {
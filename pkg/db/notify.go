@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notifier listens for Postgres NOTIFY events on a dedicated connection and
+// turns them into wake-up signals for background processors.
+//
+// Signals are coalesced: a subscriber channel holds at most one pending
+// signal, so a burst of inserts results in a single extra batch. After the
+// connection is re-established every subscriber is woken, because
+// notifications sent while it was down are lost.
+type Notifier struct {
+	listener *pq.Listener
+
+	mu          sync.Mutex
+	subscribers map[string][]chan struct{}
+}
+
+// NewNotifier creates a notifier that opens its own connection using dsn.
+func NewNotifier(dsn string) *Notifier {
+	n := &Notifier{subscribers: make(map[string][]chan struct{})}
+	n.listener = pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Postgres listener: %v", err)
+		}
+	})
+	return n
+}
+
+// Subscribe starts listening on channel and returns a channel that receives
+// a signal for every notification.
+func (n *Notifier) Subscribe(channel string) (<-chan struct{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.subscribers[channel]; !ok {
+		if err := n.listener.Listen(channel); err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			return nil, err
+		}
+	}
+
+	ch := make(chan struct{}, 1)
+	n.subscribers[channel] = append(n.subscribers[channel], ch)
+	return ch, nil
+}
+
+// Run dispatches notifications until ctx is cancelled, then closes the connection.
+func (n *Notifier) Run(ctx context.Context) {
+	defer n.listener.Close()
+
+	// The listener only notices a silently dropped connection when it is used.
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-n.listener.Notify:
+			if notification == nil {
+				// Reconnected: notifications may have been missed.
+				n.wakeAll()
+				continue
+			}
+			n.wake(notification.Channel)
+		case <-ping.C:
+			if err := n.listener.Ping(); err != nil {
+				log.Printf("Postgres listener ping failed: %v", err)
+			}
+		}
+	}
+}
+
+func (n *Notifier) wake(channel string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, ch := range n.subscribers[channel] {
+		signal(ch)
+	}
+}
+
+func (n *Notifier) wakeAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, subscribers := range n.subscribers {
+		for _, ch := range subscribers {
+			signal(ch)
+		}
+	}
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// KeyHeader — заголовок с ключом идемпотентности, который генерирует клиент (например, UUID).
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader добавляется к ответу, возвращенному из сохраненного, а не выполненному заново.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Options — параметры Middleware.
+type Options struct {
+	// Routes — шаблоны маршрутов echo ("/add/:bill_id"), для которых учитывается заголовок.
+	Routes []string
+	// TTL — сколько хранится ключ; после этого тот же ключ выполняет запрос заново.
+	TTL time.Duration
+	// LockTimeout — через сколько незавершенный запрос считается брошенным и ключ можно занять снова.
+	LockTimeout time.Duration
+}
+
+// Middleware выполняет запросы с заголовком Idempotency-Key не более одного раза:
+//   - первый запрос выполняется, его ответ сохраняется (кроме ответов 5xx — их можно повторить);
+//   - повтор с тем же телом получает сохраненный ответ с заголовком Idempotent-Replayed: true;
+//   - повтор с другим телом или параметрами отклоняется с 422;
+//   - повтор, пока первый запрос еще выполняется, отклоняется с 409.
+//
+// Запросы без заголовка и маршруты не из opts.Routes проходят без изменений.
+// Ключи действуют отдельно для каждого метода и маршрута.
+func Middleware(store *Store, opts Options) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(KeyHeader)
+			if key == "" || !slices.Contains(opts.Routes, c.Path()) {
+				return next(c)
+			}
+			if len(key) > maxKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest, KeyHeader+" must be at most "+strconv.Itoa(maxKeyLength)+" characters long")
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "failed to read request body")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			scope := c.Request().Method + " " + c.Path()
+			hash := requestHash(c.Request(), body)
+			record, acquired, err := store.Acquire(ctx, scope, key, hash, opts.TTL, opts.LockTimeout)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to check "+KeyHeader).SetInternal(err)
+			}
+
+			if !acquired {
+				switch {
+				case record.RequestHash != hash:
+					return echo.NewHTTPError(http.StatusUnprocessableEntity, KeyHeader+" was already used with a different request")
+				case !record.Completed():
+					c.Response().Header().Set("Retry-After", "1")
+					return echo.NewHTTPError(http.StatusConflict, "a request with this "+KeyHeader+" is still in progress")
+				}
+				c.Response().Header().Set(ReplayedHeader, "true")
+				return c.Blob(record.StatusCode, record.ContentType, record.Response)
+			}
+
+			return execute(c, next, store, record.ID)
+		}
+	}
+}
+
+// execute выполняет запрос под занятым ключом и сохраняет ответ. Ошибку обработчика
+// сразу превращает в ответ через c.Error, чтобы сохранить и его; после этого ошибка
+// не возвращается, иначе echo обработал бы ее второй раз.
+func execute(c echo.Context, next echo.HandlerFunc, store *Store, id int64) error {
+	recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+	c.Response().Writer = recorder
+	defer func() { c.Response().Writer = recorder.ResponseWriter }()
+
+	err := next(c)
+	if err != nil {
+		c.Error(err)
+	}
+
+	// Ответ сохраняется, даже если клиент уже отключился: он вернется на повтор
+	ctx := context.WithoutCancel(c.Request().Context())
+	status := c.Response().Status
+	if !c.Response().Committed || status >= http.StatusInternalServerError {
+		if releaseErr := store.Release(ctx, id); releaseErr != nil {
+			log.Printf("Failed to release idempotency key %d: %v", id, releaseErr)
+		}
+		return nil
+	}
+
+	contentType := c.Response().Header().Get(echo.HeaderContentType)
+	if completeErr := store.Complete(ctx, id, status, contentType, recorder.body.Bytes()); completeErr != nil {
+		// Ключ останется занятым до LockTimeout, после чего повтор выполнит запрос заново
+		log.Printf("Failed to save response for idempotency key %d: %v", id, completeErr)
+	}
+	return nil
+}
+
+// requestHash — хеш метода, пути с параметрами запроса и тела.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder копирует тело ответа, чтобы его можно было сохранить.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,104 @@
+// Package idempotency реализует заголовок Idempotency-Key для небезопасных запросов:
+// первый запрос с ключом выполняется, его ответ сохраняется в таблице idempotency_keys
+// и возвращается на повторы, пока ключ не устареет.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Record — строка таблицы idempotency_keys.
+type Record struct {
+	ID          int64
+	Scope       string
+	Key         string
+	RequestHash string
+	// StatusCode, ContentType и Response заполняются, когда запрос выполнен (CompletedAt != nil).
+	StatusCode  int
+	ContentType string
+	Response    []byte
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// Completed сообщает, сохранен ли ответ на запрос.
+func (r Record) Completed() bool {
+	return r.CompletedAt != nil
+}
+
+// Store — ключи идемпотентности в Postgres.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Acquire занимает ключ для выполнения запроса. Если ключ свободен, устарел (старше ttl)
+// или его запрос с тем же хешем брошен (выполняется дольше lockTimeout, например
+// реплика упала посреди запроса), возвращается acquired = true и id строки для Complete
+// или Release. Иначе возвращается существующая запись: выполненная или еще выполняемая.
+func (s *Store) Acquire(ctx context.Context, scope, key, requestHash string, ttl, lockTimeout time.Duration) (record Record, acquired bool, err error) {
+	// Строку могут удалить между вставкой и чтением (очистка устаревших ключей) — тогда пробуем снова
+	for range 3 {
+		err = s.db.QueryRowContext(ctx, `INSERT INTO idempotency_keys (scope, key, request_hash)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (scope, key) DO UPDATE SET
+				request_hash = EXCLUDED.request_hash,
+				status_code = NULL,
+				content_type = '',
+				response = NULL,
+				created_at = now(),
+				completed_at = NULL
+			WHERE idempotency_keys.created_at < now() - make_interval(secs => $4)
+				OR (idempotency_keys.completed_at IS NULL
+					AND idempotency_keys.request_hash = EXCLUDED.request_hash
+					AND idempotency_keys.created_at < now() - make_interval(secs => $5))
+			RETURNING id, created_at`,
+			scope, key, requestHash, ttl.Seconds(), lockTimeout.Seconds(),
+		).Scan(&record.ID, &record.CreatedAt)
+		if err == nil {
+			record.Scope, record.Key, record.RequestHash = scope, key, requestHash
+			return record, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return Record{}, false, err
+		}
+
+		record, err = s.get(ctx, scope, key)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return record, false, err
+		}
+	}
+	return Record{}, false, err
+}
+
+func (s *Store) get(ctx context.Context, scope, key string) (Record, error) {
+	var record Record
+	var statusCode sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT id, scope, key, request_hash, status_code, content_type, response, created_at, completed_at
+		FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key,
+	).Scan(&record.ID, &record.Scope, &record.Key, &record.RequestHash, &statusCode,
+		&record.ContentType, &record.Response, &record.CreatedAt, &record.CompletedAt)
+	record.StatusCode = int(statusCode.Int64)
+	return record, err
+}
+
+// Complete сохраняет ответ на запрос, выполненный под ключом id.
+func (s *Store) Complete(ctx context.Context, id int64, statusCode int, contentType string, response []byte) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE idempotency_keys
+		SET status_code = $2, content_type = $3, response = $4, completed_at = now()
+		WHERE id = $1`, id, statusCode, contentType, response)
+	return err
+}
+
+// Release освобождает ключ, ответ на который сохранять нельзя (например, внутренняя ошибка):
+// повтор с тем же ключом выполнит запрос заново.
+func (s *Store) Release(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE id = $1 AND completed_at IS NULL`, id)
+	return err
+}
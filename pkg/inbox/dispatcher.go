@@ -0,0 +1,109 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sd_hw4/pkg/tunables"
+)
+
+// Dispatcher выбирает обработчик сообщения inbox по очереди и типу сообщения.
+// Обработчики регистрируются при старте, до запуска процессоров.
+type Dispatcher struct {
+	handlers map[string]map[string]HandleFunc
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string]map[string]HandleFunc)}
+}
+
+// Register назначает обработчик сообщений типа messageType из очереди queue.
+// Повторная регистрация той же пары — ошибка в коде сервиса, поэтому вызывает панику.
+func (d *Dispatcher) Register(queue, messageType string, handler HandleFunc) {
+	types, ok := d.handlers[queue]
+	if !ok {
+		types = make(map[string]HandleFunc)
+		d.handlers[queue] = types
+	}
+	if _, exists := types[messageType]; exists {
+		panic(fmt.Sprintf("inbox: handler for %q in queue %q is already registered", messageType, queue))
+	}
+	types[messageType] = handler
+}
+
+// Queues возвращает очереди, для которых зарегистрированы обработчики.
+func (d *Dispatcher) Queues() []string {
+	queues := make([]string, 0, len(d.handlers))
+	for queue := range d.handlers {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return queues
+}
+
+// Validate проверяет, что для каждой очереди, в которую консьюмеры сохраняют сообщения,
+// зарегистрирован хотя бы один обработчик. Иначе сохраненные сообщения никто не обработает.
+func (d *Dispatcher) Validate(queues ...string) error {
+	var missing []string
+	for _, queue := range queues {
+		if len(d.handlers[queue]) == 0 {
+			missing = append(missing, queue)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("inbox: no handlers registered for queues %s (registered: %s)",
+			strings.Join(missing, ", "), strings.Join(d.Queues(), ", "))
+	}
+	return nil
+}
+
+// Handle передает сообщение зарегистрированному обработчику. Сообщение неизвестного типа
+// помещается в карантин: повтор не поможет, пока не появится обработчик.
+func (d *Dispatcher) Handle(ctx context.Context, tx *sql.Tx, msg Message) error {
+	handler, ok := d.handlers[msg.Queue][msg.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for message type %q in queue %q", msg.Type, msg.Queue))
+	}
+	return handler(ctx, tx, msg)
+}
+
+// Processors создает по процессору на каждую очередь с обработчиками. Канал пробуждения у каждого
+// процессора свой, его возвращает wake (может быть nil): уведомление, доставленное в общий канал,
+// разбудило бы только один из процессоров, а остальные ждали бы интервала опроса.
+// opts.Wake не используется.
+func (d *Dispatcher) Processors(conn *sql.DB, store Store, tunables *tunables.Processor, opts ProcessorOptions, wake func(queue string) <-chan struct{}) []*Processor {
+	var processors []*Processor
+	for _, queue := range d.Queues() {
+		queueOpts := opts
+		queueOpts.Wake = nil
+		if wake != nil {
+			queueOpts.Wake = wake(queue)
+		}
+		processors = append(processors, NewProcessor(conn, store, queue, d.Handle, tunables, queueOpts))
+	}
+	return processors
+}
+
+// JSON адаптирует обработчик типизированного payload: сообщение разбирается в T,
+// некорректный JSON считается неустранимой ошибкой (см. Permanent).
+func JSON[T any](handler func(ctx context.Context, tx *sql.Tx, payload T) error) HandleFunc {
+	return JSONMessage(func(ctx context.Context, tx *sql.Tx, _ Message, payload T) error {
+		return handler(ctx, tx, payload)
+	})
+}
+
+// JSONMessage — вариант JSON для обработчиков, которым кроме payload нужно само сообщение
+// (например, MessageID для журнала изменений).
+func JSONMessage[T any](handler func(ctx context.Context, tx *sql.Tx, msg Message, payload T) error) HandleFunc {
+	return func(ctx context.Context, tx *sql.Tx, msg Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("failed to unmarshal %s payload: %w", msg.Type, err))
+		}
+		return handler(ctx, tx, msg, payload)
+	}
+}
@@ -0,0 +1,313 @@
+package inbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"sd_hw4/pkg/admin"
+	"sd_hw4/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler — административный API для просмотра и ручной обработки сообщений inbox.
+type Handler struct {
+	db         *sql.DB
+	store      Store
+	audit      *admin.AuditLog
+	dispatcher *Dispatcher
+}
+
+// NewHandler создает административный API; dispatcher нужен для пробного прогона при replay.
+func NewHandler(conn *sql.DB, store Store, audit *admin.AuditLog, dispatcher *Dispatcher) *Handler {
+	return &Handler{db: conn, store: store, audit: audit, dispatcher: dispatcher}
+}
+
+// RegisterRoutes регистрирует маршруты в группе (обычно /admin):
+//
+//	GET  /inbox/messages                 — список; фильтры queue, type, state (pending|processed|discarded|quarantined), older_than, newer_than, limit
+//	GET  /inbox/messages/:id             — одно сообщение
+//	POST /inbox/messages/:id/reprocess   — повторная обработка обработанного, отброшенного или помещенного в карантин сообщения
+//	POST /inbox/messages/:id/discard     — отказ от обработки, тело {"note": "..."} обязательно
+//	GET  /inbox/quarantined?queue=&limit=100 — сообщения, которые не удалось обработать, с последней ошибкой
+//	POST /inbox/replay?limit=100         — повторная обработка выборки сообщений, тело ReplayRequest
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/inbox/messages", h.List)
+	g.GET("/inbox/messages/:id", h.Get)
+	g.POST("/inbox/messages/:id/reprocess", h.Reprocess)
+	g.POST("/inbox/messages/:id/discard", h.Discard)
+	g.GET("/inbox/quarantined", h.ListQuarantined)
+	g.POST("/inbox/replay", h.Replay)
+}
+
+func (h *Handler) List(c echo.Context) error {
+	filter := Filter{
+		Queue: c.QueryParam("queue"),
+		Type:  c.QueryParam("type"),
+		State: State(c.QueryParam("state")),
+	}
+	switch filter.State {
+	case "", StatePending, StateProcessed, StateDiscarded, StateQuarantined:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "state must be one of pending, processed, discarded, quarantined"})
+	}
+
+	var err error
+	if filter.Limit, err = admin.Limit(c); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if filter.OlderThan, err = admin.Age(c, "older_than"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if filter.NewerThan, err = admin.Age(c, "newer_than"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	messages, err := h.store.List(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list inbox messages"})
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+func (h *Handler) ListQuarantined(c echo.Context) error {
+	limit, err := admin.Limit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	filter := Filter{Queue: c.QueryParam("queue"), State: StateQuarantined, Limit: limit}
+	messages, err := h.store.List(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list quarantined messages"})
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+func (h *Handler) Get(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid message id"})
+	}
+
+	msg, err := h.store.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.storeError(c, err)
+	}
+	return c.JSON(http.StatusOK, msg)
+}
+
+func (h *Handler) Reprocess(c echo.Context) error {
+	return h.change(c, "inbox.reprocess", false, h.store.Reprocess)
+}
+
+func (h *Handler) Discard(c echo.Context) error {
+	return h.change(c, "inbox.discard", true, h.store.Discard)
+}
+
+// ReplayRequest выбирает сообщения для повторной обработки; пустые поля не ограничивают выборку.
+type ReplayRequest struct {
+	Queue string `json:"queue"`
+	Type  string `json:"type"`
+	State State  `json:"state"`
+	// From и To ограничивают время получения сообщения: [from, to).
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Payload — JSON-объект, который должен содержаться в payload, например {"order_id": "..."}.
+	Payload json.RawMessage `json:"payload"`
+	// DryRun только показывает, что сделали бы обработчики, ничего не меняя.
+	DryRun bool   `json:"dry_run"`
+	Note   string `json:"note"`
+}
+
+// ReplayResult — итог replay: выбранные сообщения и число возвращенных в обработку.
+type ReplayResult struct {
+	DryRun   bool              `json:"dry_run"`
+	Matched  int               `json:"matched"`
+	Reset    int               `json:"reset"`
+	Messages []ReplayedMessage `json:"messages"`
+}
+
+// ReplayedMessage — сообщение выборки. Effects и Error заполняются при пробном прогоне,
+// Skipped — если сообщение не возвращено в обработку.
+type ReplayedMessage struct {
+	ID        uuid.UUID `json:"id"`
+	MessageID string    `json:"message_id"`
+	Type      string    `json:"type,omitempty"`
+	State     State     `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	Effects   []string  `json:"effects,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Skipped   string    `json:"skipped,omitempty"`
+}
+
+// Replay возвращает в обработку сообщения очереди, выбранные по типу, состоянию, времени
+// получения и содержимому payload. Сообщения сбрасываются одной транзакцией вместе с записями
+// журнала (общей inbox.replay и inbox.reprocess на каждое сообщение). Ожидающие обработки
+// сообщения пропускаются. В режиме dry_run обработчик каждого сообщения выполняется
+// в откатываемой транзакции (см. Dispatcher.DryRun) независимо от остальных.
+func (h *Handler) Replay(c echo.Context) error {
+	var req ReplayRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	limit, err := admin.Limit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	switch {
+	case req.Queue == "":
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "queue is required"})
+	case !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from must be before to"})
+	case len(req.Payload) > 0 && !bytes.HasPrefix(bytes.TrimSpace(req.Payload), []byte("{")):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "payload must be a JSON object"})
+	case !req.DryRun && req.Note == "":
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "note is required"})
+	}
+	switch req.State {
+	case "", StatePending, StateProcessed, StateDiscarded, StateQuarantined:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "state must be one of pending, processed, discarded, quarantined"})
+	}
+
+	ctx := c.Request().Context()
+	messages, err := h.store.List(ctx, Filter{
+		Queue:   req.Queue,
+		Type:    req.Type,
+		State:   req.State,
+		From:    req.From,
+		To:      req.To,
+		Payload: req.Payload,
+		Limit:   limit,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to select inbox messages"})
+	}
+
+	result := ReplayResult{DryRun: req.DryRun, Matched: len(messages), Messages: make([]ReplayedMessage, len(messages))}
+	for i, msg := range messages {
+		result.Messages[i] = ReplayedMessage{
+			ID:        msg.ID,
+			MessageID: msg.MessageID,
+			Type:      msg.Type,
+			State:     msg.State(),
+			CreatedAt: msg.CreatedAt,
+		}
+	}
+
+	if req.DryRun {
+		for i, msg := range messages {
+			effects, err := h.dispatcher.DryRun(ctx, h.db, msg)
+			result.Messages[i].Effects = effects
+			if err != nil {
+				result.Messages[i].Error = err.Error()
+			}
+		}
+		return c.JSON(http.StatusOK, result)
+	}
+
+	actor := admin.Actor(c)
+	entry := admin.Entry{
+		Actor:    actor,
+		Action:   "inbox.replay",
+		Target:   "inbox_messages",
+		TargetID: req.Queue,
+		Note:     req.Note,
+	}
+	err = h.audit.Do(ctx, entry, func(tx *sql.Tx) error {
+		result.Reset = 0
+		for i := range result.Messages {
+			replayed := &result.Messages[i]
+			replayed.Skipped = ""
+			if replayed.State == StatePending {
+				replayed.Skipped = "already pending"
+				continue
+			}
+
+			err := h.store.Reprocess(ctx, tx, replayed.ID)
+			if errors.Is(err, ErrInvalidState) || errors.Is(err, ErrNotFound) {
+				// Сообщение успели вернуть в обработку или удалить после выборки
+				replayed.Skipped = err.Error()
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			err = h.audit.Record(ctx, tx, admin.Entry{
+				Actor:    actor,
+				Action:   "inbox.reprocess",
+				Target:   "inbox_messages",
+				TargetID: replayed.ID.String(),
+				Note:     req.Note,
+			})
+			if err != nil {
+				return err
+			}
+			result.Reset++
+		}
+		return nil
+	})
+	if err != nil {
+		return h.storeError(c, err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// change выполняет операцию над сообщением и записывает ее в журнал в одной транзакции.
+func (h *Handler) change(c echo.Context, action string, noteRequired bool,
+	apply func(ctx context.Context, exec db.Executor, id uuid.UUID) error,
+) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid message id"})
+	}
+
+	var body admin.Note
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if noteRequired && body.Note == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "note is required"})
+	}
+
+	ctx := c.Request().Context()
+	entry := admin.Entry{
+		Actor:    admin.Actor(c),
+		Action:   action,
+		Target:   "inbox_messages",
+		TargetID: id.String(),
+		Note:     body.Note,
+	}
+	err = h.audit.Do(ctx, entry, func(tx *sql.Tx) error {
+		return apply(ctx, tx, id)
+	})
+	if err != nil {
+		return h.storeError(c, err)
+	}
+
+	msg, err := h.store.Get(ctx, id)
+	if err != nil {
+		return h.storeError(c, err)
+	}
+	return c.JSON(http.StatusOK, msg)
+}
+
+func (h *Handler) storeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvalidState):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to access inbox"})
+	}
+}
@@ -0,0 +1,63 @@
+package inbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"sd_hw4/pkg/outbox"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Message — строка таблицы inbox_messages: входящее сообщение, сохраненное до обработки.
+// Повторная доставка сообщения с тем же MessageID отбрасывается.
+type Message struct {
+	ID        uuid.UUID `json:"id"`
+	MessageID string    `json:"message_id"`
+	Queue     string    `json:"queue"`
+	// Type — тип сообщения (см. pkg/contracts); вместе с Queue определяет обработчик.
+	Type string `json:"type,omitempty"`
+	// OrderingKey — ключ упорядочивания (id агрегата отправителя); сообщения с одним ключом
+	// обрабатываются строго по Seq. Пустой ключ не упорядочивается (кроме режима OrderByQueue).
+	OrderingKey string          `json:"ordering_key,omitempty"`
+	Seq         int64           `json:"seq"`
+	Payload     json.RawMessage `json:"payload"`
+	Processed   bool            `json:"processed"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// DiscardedAt заполняется, если оператор отбросил сообщение без обработки.
+	DiscardedAt *time.Time `json:"discarded_at,omitempty"`
+	// Attempts — число неудачных попыток обработки, LastError — ошибка последней из них.
+	Attempts      int       `json:"attempts"`
+	LastError     *string   `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// QuarantinedAt заполняется, когда попытки исчерпаны или ошибка неустранима повтором.
+	QuarantinedAt *time.Time `json:"quarantined_at,omitempty"`
+}
+
+// State вычисляет состояние сообщения так же, как фильтр State в Store.List.
+func (m Message) State() State {
+	switch {
+	case m.DiscardedAt != nil:
+		return StateDiscarded
+	case m.Processed:
+		return StateProcessed
+	case m.QuarantinedAt != nil:
+		return StateQuarantined
+	default:
+		return StatePending
+	}
+}
+
+// FromDelivery собирает сообщение для Save из полученного из очереди queue: идентификатор
+// и тип берутся из свойств AMQP, ключ упорядочивания — из заголовка outbox.AggregateHeader.
+func FromDelivery(queue string, delivery amqp.Delivery) Message {
+	return Message{
+		MessageID:   delivery.MessageId,
+		Queue:       queue,
+		Type:        delivery.Type,
+		OrderingKey: outbox.AggregateFromHeaders(delivery.Headers),
+		Payload:     delivery.Body,
+	}
+}
@@ -0,0 +1,105 @@
+package inbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics — метрики Prometheus для обработчиков inbox.
+type Metrics struct {
+	processed   *prometheus.CounterVec
+	failed      *prometheus.CounterVec
+	quarantined *prometheus.CounterVec
+	latency     prometheus.Histogram
+}
+
+// NewMetrics регистрирует метрики обработчиков inbox в reg с меткой service.
+func NewMetrics(service string, reg prometheus.Registerer) *Metrics {
+	labels := prometheus.Labels{"service": service}
+
+	m := &Metrics{
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "inbox_messages_processed_total",
+			Help:        "Inbox messages successfully processed.",
+			ConstLabels: labels,
+		}, []string{"queue"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "inbox_processing_failures_total",
+			Help:        "Failed attempts to process inbox messages.",
+			ConstLabels: labels,
+		}, []string{"queue"}),
+		quarantined: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "inbox_quarantined_messages_total",
+			Help:        "Inbox messages quarantined after exhausting attempts or a permanent error.",
+			ConstLabels: labels,
+		}, []string{"queue"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "inbox_processing_latency_seconds",
+			Help:        "Time between receiving an inbox message and committing its processing.",
+			ConstLabels: labels,
+			Buckets:     []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+	}
+
+	reg.MustRegister(m.processed, m.failed, m.quarantined, m.latency)
+	return m
+}
+
+// Hooks возвращает обратные вызовы обработчика, обновляющие метрики.
+func (m *Metrics) Hooks() Hooks {
+	return Hooks{
+		OnProcessed: func(msg Message, latency time.Duration) {
+			m.processed.WithLabelValues(msg.Queue).Inc()
+			m.latency.Observe(latency.Seconds())
+		},
+		OnFailed: func(msg Message, err error) {
+			m.failed.WithLabelValues(msg.Queue).Inc()
+		},
+		OnQuarantined: func(msg Message) {
+			m.quarantined.WithLabelValues(msg.Queue).Inc()
+		},
+	}
+}
+
+// stateCollector при каждом сборе метрик считает сообщения в таблице по состояниям,
+// поэтому gauge inbox_messages{state="quarantined"} не зависит от перезапусков и реплик.
+type stateCollector struct {
+	store Store
+	desc  *prometheus.Desc
+}
+
+// RegisterStateGauge регистрирует gauge inbox_messages с числом сообщений в каждом состоянии.
+func RegisterStateGauge(service string, reg prometheus.Registerer, store Store) {
+	reg.MustRegister(&stateCollector{
+		store: store,
+		desc: prometheus.NewDesc(
+			"inbox_messages",
+			"Inbox messages by state.",
+			[]string{"state"},
+			prometheus.Labels{"service": service},
+		),
+	})
+}
+
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := c.store.CountByState(ctx)
+	if err != nil {
+		log.Printf("Failed to count inbox messages: %v", err)
+		return
+	}
+
+	// Все состояния выводятся всегда, чтобы алерт на quarantined > 0 не зависел от появления ряда
+	for _, state := range []State{StatePending, StateProcessed, StateDiscarded, StateQuarantined} {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[state]), string(state))
+	}
+}
@@ -0,0 +1,188 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"sd_hw4/pkg/backoff"
+	"sd_hw4/pkg/db"
+	"sd_hw4/pkg/tunables"
+)
+
+// HandleFunc обрабатывает сообщение inbox. Все изменения в базе HandleFunc должен делать через tx:
+// в той же транзакции сообщение отмечается обработанным, поэтому у каждого сообщения
+// ровно один результат. Ошибка откатывает транзакцию, и сообщение обрабатывается повторно.
+type HandleFunc func(ctx context.Context, tx *sql.Tx, msg Message) error
+
+// permanentError — ошибка, которую повтор не исправит.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку обработки как неустранимую (например, некорректный payload):
+// сообщение сразу помещается в карантин без повторных попыток.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Hooks — необязательные обратные вызовы для метрик обработчика. Любое поле может быть nil.
+type Hooks struct {
+	// OnProcessed вызывается после фиксации обработки; latency — время от получения сообщения.
+	OnProcessed func(msg Message, latency time.Duration)
+	// OnFailed вызывается после каждой неудачной попытки обработки.
+	OnFailed func(msg Message, err error)
+	// OnQuarantined вызывается, когда сообщение помещено в карантин.
+	OnQuarantined func(msg Message)
+}
+
+// ProcessorOptions — параметры обработчика.
+type ProcessorOptions struct {
+	// Owner — идентификатор экземпляра сервиса, от имени которого захватываются сообщения.
+	Owner string
+	// Lease — срок аренды захваченной пачки; должен с запасом превышать время ее обработки.
+	Lease time.Duration
+	// Ordering — гарантия порядка обработки (по умолчанию, при пустом значении, OrderNone).
+	Ordering Ordering
+	// Retry определяет задержки между попытками и их предельное число.
+	Retry backoff.Policy
+	// Wake будит обработчик при появлении новых сообщений (см. NotifyChannel); может быть nil.
+	Wake  <-chan struct{}
+	Hooks Hooks
+}
+
+// Processor — фоновый обработчик очереди inbox. Несколько экземпляров (реплики сервиса)
+// могут обрабатывать одну очередь одновременно: каждое сообщение захватывается одним из них.
+type Processor struct {
+	db       *sql.DB
+	store    Store
+	queue    string
+	handler  HandleFunc
+	tunables *tunables.Processor
+	owner    string
+	lease    time.Duration
+	ordering Ordering
+	retry    backoff.Policy
+	wake     <-chan struct{}
+	hooks    Hooks
+}
+
+// NewProcessor создает обработчик очереди queue.
+func NewProcessor(conn *sql.DB, store Store, queue string, handler HandleFunc, tunables *tunables.Processor, opts ProcessorOptions) *Processor {
+	return &Processor{
+		db:       conn,
+		store:    store,
+		queue:    queue,
+		handler:  handler,
+		tunables: tunables,
+		owner:    opts.Owner,
+		lease:    opts.Lease,
+		ordering: opts.Ordering,
+		retry:    opts.Retry,
+		wake:     opts.Wake,
+		hooks:    opts.Hooks,
+	}
+}
+
+// Run запускает цикл обработки; завершается при отмене ctx.
+func (p *Processor) Run(ctx context.Context) {
+	p.tunables.Run(ctx, p.wake, p.ProcessBatch)
+	log.Printf("Inbox processor for %s stopped", p.queue)
+}
+
+// ProcessBatch обрабатывает готовые сообщения пачками по batchSize.
+// Следующая пачка захватывается сразу, если текущая была полной или упорядоченной:
+// за одну пачку обрабатывается не больше одного сообщения ключа (или очереди),
+// и следующие становятся доступны только после обработки.
+func (p *Processor) ProcessBatch(ctx context.Context, batchSize int) {
+	for ctx.Err() == nil {
+		claimed, processed, ordered := p.processBatch(ctx, batchSize)
+		if processed == 0 || (claimed < batchSize && !ordered) {
+			return
+		}
+	}
+}
+
+// processBatch возвращает число захваченных и обработанных сообщений и признак того,
+// что среди обработанных были упорядоченные сообщения.
+func (p *Processor) processBatch(ctx context.Context, batchSize int) (claimed, processed int, ordered bool) {
+	started := time.Now()
+
+	messages, err := p.store.Claim(ctx, p.queue, p.owner, p.lease, p.ordering, batchSize)
+	if err != nil {
+		log.Printf("Failed to claim inbox messages: %v", err)
+		return 0, 0, false
+	}
+
+	// После истечения аренды сообщения может забрать другой экземпляр
+	leaseDeadline := started.Add(p.lease)
+
+	for _, msg := range messages {
+		if ctx.Err() != nil || time.Now().After(leaseDeadline) {
+			break
+		}
+
+		err := p.process(ctx, msg)
+		switch {
+		case err == nil:
+			processed++
+			if p.ordering == OrderByQueue || (p.ordering == OrderByKey && msg.OrderingKey != "") {
+				ordered = true
+			}
+			if p.hooks.OnProcessed != nil {
+				p.hooks.OnProcessed(msg, time.Since(msg.CreatedAt))
+			}
+		case errors.Is(err, ErrInvalidState) || errors.Is(err, ErrNotFound):
+			// Сообщение уже обработано, отброшено или удалено, пока лежало в пачке
+		default:
+			log.Printf("Failed to process inbox message %s: %v", msg.MessageID, err)
+			if p.hooks.OnFailed != nil {
+				p.hooks.OnFailed(msg, err)
+			}
+			p.fail(ctx, msg, err)
+		}
+	}
+	return len(messages), processed, ordered
+}
+
+// process отмечает сообщение обработанным и вызывает HandleFunc в одной транзакции.
+// Отметка делается первой: обновление блокирует строку, и параллельный обработчик
+// (реплика, забравшая строку после истечения аренды, или повторная обработка
+// из административного API) дождется фиксации и увидит, что сообщение уже обработано.
+func (p *Processor) process(ctx context.Context, msg Message) error {
+	return db.WithTx(ctx, p.db, func(tx *sql.Tx) error {
+		if err := p.store.MarkProcessed(ctx, tx, msg.ID); err != nil {
+			return err
+		}
+		return p.handler(ctx, tx, msg)
+	})
+}
+
+// fail планирует повторную попытку или помещает сообщение в карантин, если попытки
+// исчерпаны или ошибка неустранима. Сообщение в карантине не задерживает остальные.
+func (p *Processor) fail(ctx context.Context, msg Message, handleErr error) {
+	attempts := msg.Attempts + 1
+
+	var permanent permanentError
+	if errors.As(handleErr, &permanent) || p.retry.Exhausted(attempts) {
+		if err := p.store.Quarantine(ctx, msg.ID, p.owner, handleErr.Error()); err != nil {
+			log.Printf("Failed to quarantine inbox message %s: %v", msg.MessageID, err)
+			return
+		}
+		log.Printf("Inbox message %s is quarantined after %d attempts", msg.MessageID, attempts)
+		if p.hooks.OnQuarantined != nil {
+			p.hooks.OnQuarantined(msg)
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(p.retry.Delay(attempts))
+	if err := p.store.MarkFailed(ctx, msg.ID, p.owner, handleErr.Error(), retryAt); err != nil {
+		log.Printf("Failed to record inbox message %s failure: %v", msg.MessageID, err)
+	}
+}
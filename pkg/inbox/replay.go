@@ -0,0 +1,45 @@
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"sd_hw4/pkg/db"
+)
+
+// effectsKey — ключ контекста, в котором пробный прогон собирает описания действий обработчика.
+type effectsKey struct{}
+
+// Effect описывает действие обработчика (списание, смена статуса, запись в outbox) для пробного
+// прогона (см. Dispatcher.DryRun). Вне пробного прогона вызов ничего не делает, поэтому
+// обработчики вызывают Effect всегда, не проверяя режим.
+func Effect(ctx context.Context, format string, args ...any) {
+	if effects, ok := ctx.Value(effectsKey{}).(*[]string); ok {
+		*effects = append(*effects, fmt.Sprintf(format, args...))
+	}
+}
+
+// errDryRun откатывает транзакцию пробного прогона.
+var errDryRun = errors.New("inbox: dry run")
+
+// DryRun выполняет обработчик сообщения в транзакции, которая всегда откатывается, и возвращает
+// действия, которые он описал через Effect. Ошибка обработчика возвращается как есть:
+// при настоящей обработке сообщение с такой ошибкой ушло бы на повтор или в карантин.
+// Отметка processed не меняется, поэтому прогон можно выполнять для сообщения в любом состоянии.
+func (d *Dispatcher) DryRun(ctx context.Context, conn *sql.DB, msg Message) ([]string, error) {
+	effects := []string{}
+	ctx = context.WithValue(ctx, effectsKey{}, &effects)
+
+	err := db.WithTx(ctx, conn, func(tx *sql.Tx) error {
+		if err := d.Handle(ctx, tx, msg); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return effects, err
+}
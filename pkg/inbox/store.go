@@ -0,0 +1,370 @@
+// Package inbox — transactional inbox: входящие сообщения сохраняются в таблицу
+// inbox_messages и обрабатываются фоновым обработчиком, дубли отбрасываются по message_id.
+package inbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sd_hw4/pkg/db"
+
+	"github.com/google/uuid"
+)
+
+// NotifyChannel — канал Postgres NOTIFY, в который триггер сообщает о новых строках inbox.
+const NotifyChannel = "inbox_messages"
+
+var (
+	ErrNotFound = errors.New("inbox message not found")
+	// ErrInvalidState — операция недопустима в текущем состоянии сообщения.
+	ErrInvalidState = errors.New("inbox message is in a state that does not allow this operation")
+	// ErrLeaseLost — аренда сообщения истекла и его забрал другой экземпляр.
+	ErrLeaseLost = errors.New("inbox message lease is held by another owner")
+)
+
+// Ordering — гарантия порядка обработки сообщений очереди.
+type Ordering string
+
+const (
+	// OrderNone — сообщения обрабатываются параллельно без гарантий порядка.
+	OrderNone Ordering = "none"
+	// OrderByKey — сообщения с одним OrderingKey обрабатываются строго по очереди.
+	OrderByKey Ordering = "key"
+	// OrderByQueue — вся очередь обрабатывается строго по одному сообщению в порядке получения.
+	OrderByQueue Ordering = "queue"
+)
+
+// State — состояние сообщения для фильтрации в административном API.
+type State string
+
+const (
+	StatePending   State = "pending"
+	StateProcessed State = "processed"
+	StateDiscarded State = "discarded"
+	// StateQuarantined — попытки обработки исчерпаны, сообщение ждет решения оператора.
+	StateQuarantined State = "quarantined"
+)
+
+// Filter — условия выборки сообщений; пустые поля не ограничивают выборку.
+type Filter struct {
+	Queue string
+	Type  string
+	State State
+	// OlderThan и NewerThan ограничивают возраст сообщения по created_at.
+	OlderThan time.Duration
+	NewerThan time.Duration
+	// From и To ограничивают created_at абсолютными границами: [From, To).
+	From time.Time
+	To   time.Time
+	// Payload — JSON-объект, который должен содержаться в payload сообщения (оператор @>),
+	// например {"user_id": "..."}.
+	Payload json.RawMessage
+	Limit   int
+}
+
+// Store — хранилище входящих сообщений.
+type Store interface {
+	// Save сохраняет полученное сообщение (MessageID, Queue, Type, OrderingKey, Payload);
+	// повторное сообщение с тем же MessageID игнорируется.
+	Save(ctx context.Context, msg Message) error
+	// Claim захватывает для owner на срок lease до limit готовых к обработке сообщений очереди
+	// в порядке получения. Сообщения в карантине, ожидающие повторной попытки, захваченные
+	// другим экземпляром или ожидающие предшественников (см. Ordering) пропускаются.
+	Claim(ctx context.Context, queue, owner string, lease time.Duration, ordering Ordering, limit int) ([]Message, error)
+	// MarkProcessed отмечает необработанное сообщение обработанным; exec позволяет сделать это
+	// в транзакции обработчика (см. Processor). Для уже обработанного сообщения возвращает ErrInvalidState.
+	MarkProcessed(ctx context.Context, exec db.Executor, id uuid.UUID) error
+	// MarkFailed записывает неудачную попытку обработки и откладывает следующую до retryAt.
+	// Захват снимается; если сообщением уже владеет другой экземпляр, возвращается ErrLeaseLost.
+	MarkFailed(ctx context.Context, id uuid.UUID, owner, reason string, retryAt time.Time) error
+	// Quarantine записывает последнюю неудачную попытку и помещает сообщение в карантин.
+	Quarantine(ctx context.Context, id uuid.UUID, owner, reason string) error
+	// CountByState возвращает число сообщений в каждом состоянии.
+	CountByState(ctx context.Context) (map[State]int, error)
+
+	List(ctx context.Context, filter Filter) ([]Message, error)
+	Get(ctx context.Context, id uuid.UUID) (*Message, error)
+	// Reprocess возвращает обработанное, отброшенное или помещенное в карантин сообщение
+	// в очередь обработки со сброшенным счетчиком попыток.
+	Reprocess(ctx context.Context, exec db.Executor, id uuid.UUID) error
+	// Discard помечает необработанное сообщение отброшенным: оно не будет обработано,
+	// но по-прежнему защищает от повторной доставки.
+	Discard(ctx context.Context, exec db.Executor, id uuid.UUID) error
+}
+
+// PostgresStore — реализация Store поверх таблицы inbox_messages.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const messageColumns = `id, message_id, queue, message_type, ordering_key, seq, payload, processed, processed_at, created_at, discarded_at,
+	attempts, last_error, next_attempt_at, quarantined_at`
+
+// stateExpr вычисляет State строки; порядок проверок совпадает с условиями фильтра в List.
+const stateExpr = `CASE
+	WHEN discarded_at IS NOT NULL THEN 'discarded'
+	WHEN processed THEN 'processed'
+	WHEN quarantined_at IS NOT NULL THEN 'quarantined'
+	ELSE 'pending' END`
+
+func (s *PostgresStore) Save(ctx context.Context, msg Message) error {
+	query := `
+		INSERT INTO inbox_messages (id, message_id, queue, message_type, ordering_key, payload, processed, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (message_id) DO NOTHING
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		uuid.New(), msg.MessageID, msg.Queue, msg.Type, msg.OrderingKey, []byte(msg.Payload), false, time.Now())
+	return err
+}
+
+// claimable — условие, при котором строку m можно захватить: сообщение не обработано,
+// не в карантине, время очередной попытки наступило, аренда другого экземпляра не действует.
+const claimable = `m.queue = $1
+	AND m.processed = false
+	AND m.quarantined_at IS NULL
+	AND m.next_attempt_at <= now()
+	AND (m.lease_until IS NULL OR m.lease_until < now())`
+
+// waiting — необработанные предшественники m (меньший seq), которые по Ordering должны быть
+// обработаны раньше. Предшественник, ожидающий повтора, задерживает последователей;
+// сообщение в карантине — нет, иначе оно остановило бы всю очередь или ключ.
+const waiting = `SELECT 1 FROM inbox_messages p
+	WHERE p.queue = m.queue
+		AND p.seq < m.seq
+		AND p.processed = false
+		AND p.quarantined_at IS NULL`
+
+// Claim выбирает строки с FOR UPDATE SKIP LOCKED, поэтому реплики не получают одни и те же
+// строки и не ждут друг друга; аренда защищает строки и после фиксации захвата.
+// Аренда упавшего экземпляра истекает, и его строки снова становятся доступны.
+func (s *PostgresStore) Claim(ctx context.Context, queue, owner string, lease time.Duration, ordering Ordering, limit int) ([]Message, error) {
+	condition := claimable
+	switch ordering {
+	case OrderByKey:
+		condition += ` AND (m.ordering_key IS NULL OR NOT EXISTS (` + waiting + ` AND p.ordering_key = m.ordering_key))`
+	case OrderByQueue:
+		condition += ` AND NOT EXISTS (` + waiting + `)`
+	}
+
+	query := `UPDATE inbox_messages
+		SET claimed_by = $2, lease_until = now() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT m.id FROM inbox_messages m
+			WHERE ` + condition + `
+			ORDER BY m.seq ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + messageColumns
+
+	rows, err := s.db.QueryContext(ctx, query, queue, owner, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Seq < messages[j].Seq
+	})
+	return messages, nil
+}
+
+func (s *PostgresStore) MarkProcessed(ctx context.Context, exec db.Executor, id uuid.UUID) error {
+	query := `UPDATE inbox_messages SET processed = true, processed_at = now(), lease_until = NULL
+		WHERE id = $1 AND processed = false`
+	result, err := exec.ExecContext(ctx, query, id)
+	return checkUpdated(ctx, exec, id, result, err)
+}
+
+func (s *PostgresStore) MarkFailed(ctx context.Context, id uuid.UUID, owner, reason string, retryAt time.Time) error {
+	query := `UPDATE inbox_messages
+		SET attempts = attempts + 1, last_error = $3, next_attempt_at = $4, claimed_by = NULL, lease_until = NULL
+		WHERE id = $1 AND claimed_by = $2 AND processed = false`
+	return checkLease(s.db.ExecContext(ctx, query, id, owner, reason, retryAt))
+}
+
+func (s *PostgresStore) Quarantine(ctx context.Context, id uuid.UUID, owner, reason string) error {
+	query := `UPDATE inbox_messages
+		SET attempts = attempts + 1, last_error = $3, quarantined_at = now(), claimed_by = NULL, lease_until = NULL
+		WHERE id = $1 AND claimed_by = $2 AND processed = false`
+	return checkLease(s.db.ExecContext(ctx, query, id, owner, reason))
+}
+
+func (s *PostgresStore) CountByState(ctx context.Context) (map[State]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+stateExpr+`, count(*) FROM inbox_messages GROUP BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[State]int)
+	for rows.Next() {
+		var state State
+		var count int
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, err
+		}
+		counts[state] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *PostgresStore) List(ctx context.Context, filter Filter) ([]Message, error) {
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Queue != "" {
+		conditions = append(conditions, "queue = "+arg(filter.Queue))
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "message_type = "+arg(filter.Type))
+	}
+	switch filter.State {
+	case StatePending:
+		conditions = append(conditions, "processed = false AND quarantined_at IS NULL")
+	case StateQuarantined:
+		conditions = append(conditions, "processed = false AND quarantined_at IS NOT NULL")
+	case StateProcessed:
+		conditions = append(conditions, "processed = true AND discarded_at IS NULL")
+	case StateDiscarded:
+		conditions = append(conditions, "discarded_at IS NOT NULL")
+	}
+	if filter.OlderThan > 0 {
+		conditions = append(conditions, "created_at < now() - make_interval(secs => "+arg(filter.OlderThan.Seconds())+")")
+	}
+	if filter.NewerThan > 0 {
+		conditions = append(conditions, "created_at >= now() - make_interval(secs => "+arg(filter.NewerThan.Seconds())+")")
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(filter.To))
+	}
+	if len(filter.Payload) > 0 {
+		conditions = append(conditions, "payload @> "+arg(string(filter.Payload))+"::jsonb")
+	}
+
+	query := `SELECT ` + messageColumns + ` FROM inbox_messages`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC LIMIT ` + arg(filter.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id uuid.UUID) (*Message, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+messageColumns+` FROM inbox_messages WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrNotFound
+	}
+	return &messages[0], nil
+}
+
+func (s *PostgresStore) Reprocess(ctx context.Context, exec db.Executor, id uuid.UUID) error {
+	query := `UPDATE inbox_messages SET processed = false, processed_at = NULL, discarded_at = NULL,
+			attempts = 0, next_attempt_at = now(), quarantined_at = NULL, claimed_by = NULL, lease_until = NULL
+		WHERE id = $1 AND (processed = true OR quarantined_at IS NOT NULL)`
+	result, err := exec.ExecContext(ctx, query, id)
+	return checkUpdated(ctx, exec, id, result, err)
+}
+
+func (s *PostgresStore) Discard(ctx context.Context, exec db.Executor, id uuid.UUID) error {
+	query := `UPDATE inbox_messages SET processed = true, processed_at = now(), discarded_at = now()
+		WHERE id = $1 AND processed = false`
+	result, err := exec.ExecContext(ctx, query, id)
+	return checkUpdated(ctx, exec, id, result, err)
+}
+
+// checkUpdated отличает отсутствующее сообщение от сообщения в неподходящем состоянии.
+func checkUpdated(ctx context.Context, exec db.Executor, id uuid.UUID, result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := exec.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM inbox_messages WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrInvalidState
+}
+
+// checkLease возвращает ErrLeaseLost, если строка не обновлена: ее захватил другой экземпляр
+// или она уже обработана.
+func checkLease(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	messages := []Message{}
+	for rows.Next() {
+		var msg Message
+		var messageType, orderingKey sql.NullString
+		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.Queue, &messageType, &orderingKey, &msg.Seq, &msg.Payload,
+			&msg.Processed, &msg.ProcessedAt, &msg.CreatedAt, &msg.DiscardedAt,
+			&msg.Attempts, &msg.LastError, &msg.NextAttemptAt, &msg.QuarantinedAt)
+		if err != nil {
+			return nil, err
+		}
+		msg.Type = messageType.String
+		msg.OrderingKey = orderingKey.String
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
@@ -1,6 +1,7 @@
 package messaging
 
 import (
+	"context"
 	"errors"
 	"log"
 	"sync"
@@ -101,6 +102,17 @@ func (c *Connection) Channel() (*amqp.Channel, error) {
 	return c.channel, nil
 }
 
+// Publish отправляет готовое сообщение в exchange с заданным routing key.
+// В отличие от Publisher, адрес и свойства сообщения (MessageId, заголовки) задает вызывающий.
+func (c *Connection) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	ch, err := c.Channel()
+	if err != nil {
+		return err
+	}
+
+	return ch.PublishWithContext(ctx, exchange, routingKey, false, false, msg)
+}
+
 func (c *Connection) IsConnected() bool {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
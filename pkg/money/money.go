@@ -0,0 +1,266 @@
+// Package money — денежные суммы без ошибок округления: целое число минимальных единиц
+// (копеек) и код валюты ISO 4217. Суммы в базе хранятся в столбцах decimal(…, 2) и передаются
+// в запросы и из результатов строкой (см. Decimal, ParseSigned), в API и сообщениях — объектом
+// {"amount": "123.45", "currency": "RUB"}, чтобы клиенты не приводили сумму к float.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency — код валюты ISO 4217. Поддерживаются валюты с двумя знаками после запятой.
+type Currency string
+
+const (
+	RUB Currency = "RUB"
+	// DefaultCurrency — валюта каталога и счетов; подставляется, если в JSON валюта не указана.
+	DefaultCurrency = RUB
+)
+
+// scale — число минимальных единиц в единице валюты (два знака после запятой).
+const scale = 100
+
+var (
+	// ErrInvalidAmount — строка не является десятичным числом вида 123 или 123.45
+	// (экспонента, NaN, Inf, пробелы и знак "+" не допускаются).
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrPrecision — у суммы больше двух знаков после запятой; округление не выполняется.
+	ErrPrecision = errors.New("amount has more than 2 decimal places")
+	ErrNegative  = errors.New("amount must not be negative")
+	// ErrOverflow — сумма или результат операции не помещается в int64 минимальных единиц.
+	ErrOverflow         = errors.New("amount is out of range")
+	ErrInvalidCurrency  = errors.New("invalid currency code")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// ParseCurrency проверяет код валюты: три заглавные латинские буквы.
+func ParseCurrency(code string) (Currency, error) {
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w %q", ErrInvalidCurrency, code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%w %q", ErrInvalidCurrency, code)
+		}
+	}
+	return Currency(code), nil
+}
+
+// Money — сумма в валюте. Нулевое значение — ноль без валюты; суммы с валютой получают
+// через Zero, FromMinor или Parse.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// Zero возвращает нулевую сумму в валюте currency.
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// FromMinor возвращает сумму minor минимальных единиц (копеек) валюты currency.
+func FromMinor(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Parse разбирает неотрицательную сумму вида "123", "123.4" или "123.45".
+// Больше двух знаков после запятой — ErrPrecision, знак "-" — ErrNegative.
+func Parse(s string, currency Currency) (Money, error) {
+	if strings.HasPrefix(s, "-") {
+		return Money{}, fmt.Errorf("%w: %q", ErrNegative, s)
+	}
+	return ParseSigned(s, currency)
+}
+
+// ParseSigned разбирает сумму как Parse, но допускает знак "-". Используется для значений,
+// прочитанных из базы.
+func ParseSigned(s string, currency Currency) (Money, error) {
+	if _, err := ParseCurrency(string(currency)); err != nil {
+		return Money{}, err
+	}
+
+	digits, negative := strings.CutPrefix(s, "-")
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || hasPoint && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(frac) > 2 {
+		return Money{}, fmt.Errorf("%w: %q", ErrPrecision, s)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/scale {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	cents := 0
+	if frac != "" {
+		cents, _ = strconv.Atoi(frac)
+		if len(frac) == 1 {
+			cents *= 10
+		}
+	}
+
+	minor := units*scale + int64(cents)
+	if minor < 0 {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor возвращает сумму в минимальных единицах (копейках).
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// Add возвращает m + other; суммы в разных валютах не складываются (ErrCurrencyMismatch).
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum := m.minor + other.minor
+	if (other.minor > 0 && sum < m.minor) || (other.minor < 0 && sum > m.minor) {
+		return Money{}, ErrOverflow
+	}
+	return Money{minor: sum, currency: m.currency}, nil
+}
+
+// Sub возвращает m - other; результат может быть отрицательным.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.minor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{minor: -other.minor, currency: other.currency})
+}
+
+// Mul возвращает сумму, умноженную на количество n.
+func (m Money) Mul(n int64) (Money, error) {
+	if n != 0 && m.minor != 0 {
+		product := m.minor * n
+		if product/n != m.minor || (m.minor == -1 && n == math.MinInt64) || (n == -1 && m.minor == math.MinInt64) {
+			return Money{}, ErrOverflow
+		}
+		return Money{minor: product, currency: m.currency}, nil
+	}
+	return Money{currency: m.currency}, nil
+}
+
+// Compare возвращает -1, 0 или 1, если m меньше, равна или больше other.
+func (m Money) Compare(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < other.minor:
+		return -1, nil
+	case m.minor > other.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.currency != other.currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return nil
+}
+
+// Decimal возвращает сумму без валюты с двумя знаками после запятой ("123.45", "-0.50").
+// В этом виде сумма передается в столбцы decimal.
+func (m Money) Decimal() string {
+	sign := ""
+	minor := uint64(m.minor)
+	if m.minor < 0 {
+		sign = "-"
+		minor = uint64(-(m.minor + 1)) + 1
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/scale, minor%scale)
+}
+
+// String возвращает сумму с валютой: "123.45 RUB".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.currency)
+}
+
+// jsonMoney — JSON-представление суммы.
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency Currency        `json:"currency,omitempty"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.Decimal())
+	return json.Marshal(jsonMoney{Amount: amount, Currency: m.currency})
+}
+
+// UnmarshalJSON принимает объект {"amount": "123.45", "currency": "RUB"}; сумма может быть
+// и числом, разбирается по тексту и так же строго, как в Parse, отрицательные суммы
+// не допускаются. Без валюты используется DefaultCurrency. Сумма числом или строкой без объекта
+// принимается в валюте DefaultCurrency: в таком виде суммы передавались до появления пакета.
+// null, как принято для json.Unmarshaler, оставляет m без изменений.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	value := jsonMoney{Amount: data}
+	if bytes.HasPrefix(data, []byte("{")) {
+		value = jsonMoney{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	currency := DefaultCurrency
+	if value.Currency != "" {
+		currency = value.Currency
+	}
+
+	amount := string(value.Amount)
+	if strings.HasPrefix(amount, `"`) {
+		if err := json.Unmarshal(value.Amount, &amount); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,285 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in    string
+		minor int64
+		err   error
+	}{
+		{in: "0", minor: 0},
+		{in: "123", minor: 12300},
+		{in: "123.4", minor: 12340},
+		{in: "123.45", minor: 12345},
+		{in: "0.05", minor: 5},
+		{in: "007.10", minor: 710},
+		{in: "92233720368547758.07", minor: math.MaxInt64},
+
+		{in: "", err: ErrInvalidAmount},
+		{in: "1.", err: ErrInvalidAmount},
+		{in: ".5", err: ErrInvalidAmount},
+		{in: "+1", err: ErrInvalidAmount},
+		{in: "1e3", err: ErrInvalidAmount},
+		{in: "1.2.3", err: ErrInvalidAmount},
+		{in: " 1", err: ErrInvalidAmount},
+		{in: "1,5", err: ErrInvalidAmount},
+		{in: "NaN", err: ErrInvalidAmount},
+		{in: "Inf", err: ErrInvalidAmount},
+		{in: "1.234", err: ErrPrecision},
+		{in: "-1", err: ErrNegative},
+		{in: "-0.01", err: ErrNegative},
+		{in: "92233720368547758.08", err: ErrOverflow},
+		{in: "92233720368547759", err: ErrOverflow},
+		{in: "99999999999999999999", err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, RUB)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Parse(%q) error = %v, want %v", tt.in, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.in, err)
+			}
+			if got.Minor() != tt.minor || got.Currency() != RUB {
+				t.Fatalf("Parse(%q) = %d %s, want %d RUB", tt.in, got.Minor(), got.Currency(), tt.minor)
+			}
+		})
+	}
+}
+
+func TestParseSigned(t *testing.T) {
+	tests := []struct {
+		in    string
+		minor int64
+		err   error
+	}{
+		{in: "-0.50", minor: -50},
+		{in: "-123.45", minor: -12345},
+		{in: "-92233720368547758.07", minor: -math.MaxInt64},
+		{in: "-", err: ErrInvalidAmount},
+		{in: "--1", err: ErrInvalidAmount},
+		{in: "-1.234", err: ErrPrecision},
+		{in: "-92233720368547758.08", err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSigned(tt.in, RUB)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParseSigned(%q) error = %v, want %v", tt.in, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSigned(%q) error = %v", tt.in, err)
+			}
+			if got.Minor() != tt.minor {
+				t.Fatalf("ParseSigned(%q) = %d, want %d", tt.in, got.Minor(), tt.minor)
+			}
+		})
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	for _, code := range []string{"", "RU", "RUBL", "rub", "R1B"} {
+		if _, err := Parse("1", Currency(code)); !errors.Is(err, ErrInvalidCurrency) {
+			t.Errorf("Parse with currency %q error = %v, want %v", code, err, ErrInvalidCurrency)
+		}
+	}
+	if got, err := ParseCurrency("USD"); err != nil || got != "USD" {
+		t.Errorf("ParseCurrency(USD) = %q, %v", got, err)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	rub := func(minor int64) Money { return FromMinor(minor, RUB) }
+
+	tests := []struct {
+		name  string
+		op    func() (Money, error)
+		minor int64
+		err   error
+	}{
+		{name: "add", op: func() (Money, error) { return rub(150).Add(rub(250)) }, minor: 400},
+		{name: "add negative", op: func() (Money, error) { return rub(150).Add(rub(-250)) }, minor: -100},
+		{name: "add max", op: func() (Money, error) { return rub(math.MaxInt64 - 1).Add(rub(1)) }, minor: math.MaxInt64},
+		{name: "add overflow", op: func() (Money, error) { return rub(math.MaxInt64).Add(rub(1)) }, err: ErrOverflow},
+		{name: "add underflow", op: func() (Money, error) { return rub(math.MinInt64).Add(rub(-1)) }, err: ErrOverflow},
+		{name: "add currency mismatch", op: func() (Money, error) { return rub(1).Add(FromMinor(1, "USD")) }, err: ErrCurrencyMismatch},
+
+		{name: "sub", op: func() (Money, error) { return rub(100).Sub(rub(250)) }, minor: -150},
+		{name: "sub min", op: func() (Money, error) { return rub(-1).Sub(rub(math.MaxInt64)) }, minor: math.MinInt64},
+		{name: "sub overflow", op: func() (Money, error) { return rub(math.MaxInt64).Sub(rub(-1)) }, err: ErrOverflow},
+		{name: "sub underflow", op: func() (Money, error) { return rub(math.MinInt64).Sub(rub(1)) }, err: ErrOverflow},
+		{name: "sub min operand", op: func() (Money, error) { return rub(0).Sub(rub(math.MinInt64)) }, err: ErrOverflow},
+		{name: "sub currency mismatch", op: func() (Money, error) { return rub(1).Sub(FromMinor(1, "USD")) }, err: ErrCurrencyMismatch},
+
+		{name: "mul", op: func() (Money, error) { return rub(1999).Mul(3) }, minor: 5997},
+		{name: "mul zero quantity", op: func() (Money, error) { return rub(math.MaxInt64).Mul(0) }, minor: 0},
+		{name: "mul zero amount", op: func() (Money, error) { return rub(0).Mul(math.MaxInt64) }, minor: 0},
+		{name: "mul negative", op: func() (Money, error) { return rub(-50).Mul(3) }, minor: -150},
+		{name: "mul max", op: func() (Money, error) { return rub(math.MaxInt64).Mul(1) }, minor: math.MaxInt64},
+		{name: "mul overflow", op: func() (Money, error) { return rub(math.MaxInt64/2 + 1).Mul(2) }, err: ErrOverflow},
+		{name: "mul large overflow", op: func() (Money, error) { return rub(99_999_999_99).Mul(math.MaxInt32 * 1000) }, err: ErrOverflow},
+		{name: "mul min by -1", op: func() (Money, error) { return rub(math.MinInt64).Mul(-1) }, err: ErrOverflow},
+		{name: "mul -1 by min", op: func() (Money, error) { return rub(-1).Mul(math.MinInt64) }, err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op()
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got.Minor() != tt.minor || got.Currency() != RUB {
+				t.Fatalf("got %d %s, want %d RUB", got.Minor(), got.Currency(), tt.minor)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b Money
+		want int
+	}{
+		{FromMinor(1, RUB), FromMinor(2, RUB), -1},
+		{FromMinor(2, RUB), FromMinor(2, RUB), 0},
+		{FromMinor(3, RUB), FromMinor(-3, RUB), 1},
+	}
+	for _, tt := range tests {
+		got, err := tt.a.Compare(tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, %v, want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := FromMinor(1, RUB).Compare(FromMinor(1, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Compare with other currency error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		minor int64
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-50, "-0.50"},
+		{12345, "123.45"},
+		{-12345, "-123.45"},
+		{math.MaxInt64, "92233720368547758.07"},
+		{math.MinInt64, "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := FromMinor(tt.minor, RUB).Decimal(); got != tt.want {
+			t.Errorf("Decimal(%d) = %q, want %q", tt.minor, got, tt.want)
+		}
+	}
+	if got := FromMinor(-12345, RUB).String(); got != "-123.45 RUB" {
+		t.Errorf("String() = %q, want %q", got, "-123.45 RUB")
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(FromMinor(12345, RUB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"123.45","currency":"RUB"}`; string(data) != want {
+		t.Fatalf("Marshal = %s, want %s", data, want)
+	}
+
+	var got Money
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != FromMinor(12345, RUB) {
+		t.Fatalf("round trip = %s, want 123.45 RUB", got)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		minor    int64
+		currency Currency
+		err      error
+	}{
+		{name: "object", in: `{"amount":"123.45","currency":"USD"}`, minor: 12345, currency: "USD"},
+		{name: "object with number amount", in: `{"amount":123.45,"currency":"RUB"}`, minor: 12345, currency: RUB},
+		{name: "object without currency", in: `{"amount":"1.5"}`, minor: 150, currency: DefaultCurrency},
+		{name: "bare number", in: `123.45`, minor: 12345, currency: DefaultCurrency},
+		{name: "bare integer", in: `100`, minor: 10000, currency: DefaultCurrency},
+		{name: "bare string", in: `"0.99"`, minor: 99, currency: DefaultCurrency},
+		{name: "spaces around value", in: " 7 ", minor: 700, currency: DefaultCurrency},
+
+		{name: "object without amount", in: `{"currency":"RUB"}`, err: ErrInvalidAmount},
+		{name: "invalid currency", in: `{"amount":"1","currency":"rub"}`, err: ErrInvalidCurrency},
+		{name: "negative number", in: `-1`, err: ErrNegative},
+		{name: "negative string", in: `{"amount":"-1.00"}`, err: ErrNegative},
+		{name: "exponent", in: `1e3`, err: ErrInvalidAmount},
+		{name: "exponent in object", in: `{"amount":1e3}`, err: ErrInvalidAmount},
+		{name: "too precise number", in: `0.001`, err: ErrPrecision},
+		{name: "too precise string", in: `{"amount":"0.001"}`, err: ErrPrecision},
+		{name: "overflow", in: `99999999999999999999`, err: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Unmarshal(%s) error = %v, want %v", tt.in, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.in, err)
+			}
+			if got.Minor() != tt.minor || got.Currency() != tt.currency {
+				t.Fatalf("Unmarshal(%s) = %s, want %d %s", tt.in, got, tt.minor, tt.currency)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	got := FromMinor(500, RUB)
+	if err := json.Unmarshal([]byte(`null`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != FromMinor(500, RUB) {
+		t.Fatalf("null changed value to %s", got)
+	}
+
+	var request struct {
+		Amount *Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":null}`), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Amount != nil {
+		t.Fatalf("amount = %s, want nil", request.Amount)
+	}
+}
@@ -0,0 +1,222 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"sd_hw4/pkg/pgoutput"
+
+	"github.com/google/uuid"
+)
+
+// CDCOptions — параметры relay на логической репликации.
+type CDCOptions struct {
+	// DSN — строка подключения к базе с outbox; пользователю нужно право REPLICATION.
+	DSN string
+	// Slot — имя логического слота репликации, создается при первом запуске.
+	Slot string
+	// Publication — публикация таблицы outbox_messages (создается миграцией).
+	Publication string
+	// StatusInterval — как часто подтверждать серверу обработанную позицию.
+	StatusInterval time.Duration
+	// ReconnectDelay — пауза перед повторным подключением после ошибки.
+	ReconnectDelay time.Duration
+}
+
+// CDCRelay читает вставки в outbox_messages из WAL через слот логической репликации (pgoutput)
+// и публикует их в порядке фиксации транзакций, без опроса таблицы.
+//
+// Каждое сообщение перед публикацией захватывается так же, как в Relay (ClaimOne), поэтому
+// CDC-relay можно запускать вместе с опросным Relay: опрос забирает повторы неудачных публикаций,
+// сообщения, ожидающие предшественников по агрегату, и строки, записанные до создания слота.
+// Одновременно поток слота может читать только один экземпляр, остальные ждут и переподключаются.
+type CDCRelay struct {
+	relay   *Relay
+	offsets *cdcOffsets
+	opts    CDCOptions
+}
+
+// NewCDCRelay создает CDC-relay; relay задает хранилище, брокер, аренду, повторы и метрики.
+// Нулевые интервалы заменяются значениями по умолчанию: 10s для подтверждений и 5s для переподключения.
+func NewCDCRelay(relay *Relay, conn *sql.DB, opts CDCOptions) *CDCRelay {
+	if opts.StatusInterval <= 0 {
+		opts.StatusInterval = 10 * time.Second
+	}
+	if opts.ReconnectDelay <= 0 {
+		opts.ReconnectDelay = 5 * time.Second
+	}
+	return &CDCRelay{
+		relay:   relay,
+		offsets: &cdcOffsets{db: conn},
+		opts:    opts,
+	}
+}
+
+// Run читает поток изменений до отмены ctx, переподключаясь после ошибок.
+func (r *CDCRelay) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := r.stream(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		log.Printf("Outbox CDC stream stopped: %v, reconnecting in %s", err, r.opts.ReconnectDelay)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(r.opts.ReconnectDelay):
+		}
+	}
+	log.Println("Outbox CDC relay stopped")
+}
+
+func (r *CDCRelay) stream(ctx context.Context) error {
+	conn, err := pgoutput.Connect(ctx, r.opts.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	created, err := conn.EnsureSlot(ctx, r.opts.Slot)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("Created replication slot %s; messages written before it are published by polling", r.opts.Slot)
+	}
+
+	confirmed, err := r.offsets.load(ctx, r.opts.Slot)
+	if err != nil {
+		return err
+	}
+	if err := conn.Start(ctx, r.opts.Slot, r.opts.Publication, confirmed); err != nil {
+		return err
+	}
+	log.Printf("Outbox CDC relay streaming from slot %s at %s", r.opts.Slot, confirmed)
+
+	var (
+		inTransaction bool
+		inserted      []uuid.UUID
+		nextStatus    = time.Now().Add(r.opts.StatusInterval)
+	)
+
+	for {
+		receiveCtx, cancel := context.WithDeadline(ctx, nextStatus)
+		msg, err := conn.Receive(receiveCtx)
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !pgoutput.Timeout(err) {
+				return err
+			}
+		}
+
+		switch m := msg.(type) {
+		case pgoutput.Keepalive:
+			// Вне транзакции все изменения до конца WAL сервера уже обработаны
+			if !inTransaction && m.ServerWALEnd > confirmed {
+				confirmed = m.ServerWALEnd
+			}
+			if !m.ReplyRequested {
+				continue
+			}
+		case pgoutput.XLogData:
+			switch change := m.Message.(type) {
+			case pgoutput.Begin:
+				inTransaction = true
+				inserted = inserted[:0]
+			case pgoutput.Insert:
+				if id, ok := publishable(change.Row); ok {
+					inserted = append(inserted, id)
+				}
+			case pgoutput.Update:
+				// Возврат сообщения к публикации через административный API
+				if id, ok := publishable(change.Row); ok {
+					inserted = append(inserted, id)
+				}
+			case pgoutput.Commit:
+				for _, id := range inserted {
+					r.deliver(ctx, id)
+				}
+				inTransaction = false
+				confirmed = change.EndLSN
+				if len(inserted) > 0 {
+					if err := r.offsets.save(ctx, r.opts.Slot, confirmed); err != nil {
+						log.Printf("Failed to save outbox CDC position: %v", err)
+					}
+				}
+			}
+			continue
+		}
+
+		// Тайм-аут ожидания или запрос сервера: подтверждаем обработанную позицию
+		if err := conn.SendStandbyStatus(confirmed); err != nil {
+			return err
+		}
+		nextStatus = time.Now().Add(r.opts.StatusInterval)
+	}
+}
+
+// deliver захватывает и публикует сообщение. Если захватить не удалось, сообщение уже отправлено,
+// отброшено, обрабатывается другим relay или ждет предшественников — тогда его опубликует опрос.
+func (r *CDCRelay) deliver(ctx context.Context, id uuid.UUID) {
+	msg, err := r.relay.store.ClaimOne(ctx, id, r.relay.owner, r.relay.lease)
+	if err != nil {
+		log.Printf("Failed to claim outbox message %s from CDC stream: %v", id, err)
+		return
+	}
+	if msg == nil {
+		return
+	}
+	r.relay.deliver(ctx, *msg)
+}
+
+// publishable возвращает id строки outbox, которая ожидает публикации и никем не захвачена.
+// Собственные изменения relay (захват, отметка об отправке) под это условие не попадают.
+func publishable(row pgoutput.Row) (uuid.UUID, bool) {
+	if row.Relation.Name != "outbox_messages" {
+		return uuid.Nil, false
+	}
+
+	status, claimedBy, rawID := row.Values["status"], row.Values["claimed_by"], row.Values["id"]
+	if status == nil || Status(*status) != StatusPending || claimedBy != nil || rawID == nil {
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(*rawID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// cdcOffsets хранит подтвержденную позицию слота в таблице outbox_cdc_offsets.
+// Сервер и сам помнит позицию слота; собственная копия позволяет видеть ее без прав
+// на pg_replication_slots и не зависит от пересоздания слота с тем же именем.
+type cdcOffsets struct {
+	db *sql.DB
+}
+
+func (o *cdcOffsets) load(ctx context.Context, slot string) (pgoutput.LSN, error) {
+	var lsn string
+	err := o.db.QueryRowContext(ctx, `SELECT lsn::text FROM outbox_cdc_offsets WHERE slot_name = $1`, slot).Scan(&lsn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return pgoutput.ParseLSN(lsn)
+}
+
+func (o *cdcOffsets) save(ctx context.Context, slot string, lsn pgoutput.LSN) error {
+	query := `INSERT INTO outbox_cdc_offsets (slot_name, lsn, updated_at) VALUES ($1, $2::pg_lsn, now())
+		ON CONFLICT (slot_name) DO UPDATE SET lsn = EXCLUDED.lsn, updated_at = EXCLUDED.updated_at`
+	_, err := o.db.ExecContext(ctx, query, slot, lsn.String())
+	return err
+}
@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"sd_hw4/pkg/admin"
+	"sd_hw4/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler — административный API для просмотра и ручного управления сообщениями outbox.
+type Handler struct {
+	store Store
+	audit *admin.AuditLog
+}
+
+func NewHandler(store Store, audit *admin.AuditLog) *Handler {
+	return &Handler{store: store, audit: audit}
+}
+
+// RegisterRoutes регистрирует маршруты в группе (обычно /admin):
+//
+//	GET  /outbox/messages               — список; фильтры status, exchange, routing_key, aggregate_id, older_than, newer_than, limit
+//	GET  /outbox/messages/:id           — одно сообщение
+//	POST /outbox/messages/:id/requeue   — повторная публикация сообщения в статусе failed или dead
+//	POST /outbox/messages/:id/discard   — отказ от публикации, тело {"note": "..."} обязательно
+//	GET  /outbox/dead?limit=100         — сообщения, которые так и не удалось опубликовать
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/outbox/messages", h.List)
+	g.GET("/outbox/messages/:id", h.Get)
+	g.POST("/outbox/messages/:id/requeue", h.Requeue)
+	g.POST("/outbox/messages/:id/discard", h.Discard)
+	g.GET("/outbox/dead", h.ListDead)
+}
+
+func (h *Handler) List(c echo.Context) error {
+	filter := Filter{
+		Status:      Status(c.QueryParam("status")),
+		Exchange:    c.QueryParam("exchange"),
+		RoutingKey:  c.QueryParam("routing_key"),
+		AggregateID: c.QueryParam("aggregate_id"),
+	}
+
+	var err error
+	if filter.Limit, err = admin.Limit(c); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if filter.OlderThan, err = admin.Age(c, "older_than"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if filter.NewerThan, err = admin.Age(c, "newer_than"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	messages, err := h.store.List(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list outbox messages"})
+	}
+	if messages == nil {
+		messages = []Message{}
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+func (h *Handler) Get(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid message id"})
+	}
+
+	msg, err := h.store.Get(c.Request().Context(), id)
+	if err != nil {
+		return h.storeError(c, err)
+	}
+	return c.JSON(http.StatusOK, msg)
+}
+
+func (h *Handler) Requeue(c echo.Context) error {
+	return h.change(c, "outbox.requeue", false, h.store.Requeue)
+}
+
+func (h *Handler) Discard(c echo.Context) error {
+	return h.change(c, "outbox.discard", true, h.store.Discard)
+}
+
+func (h *Handler) ListDead(c echo.Context) error {
+	limit, err := admin.Limit(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	messages, err := h.store.ListByStatus(c.Request().Context(), StatusDead, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list dead messages"})
+	}
+	if messages == nil {
+		messages = []Message{}
+	}
+	return c.JSON(http.StatusOK, messages)
+}
+
+// change выполняет операцию над сообщением и записывает ее в журнал в одной транзакции.
+func (h *Handler) change(c echo.Context, action string, noteRequired bool,
+	apply func(ctx context.Context, exec db.Executor, id uuid.UUID) error,
+) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid message id"})
+	}
+
+	var body admin.Note
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if noteRequired && body.Note == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "note is required"})
+	}
+
+	ctx := c.Request().Context()
+	entry := admin.Entry{
+		Actor:    admin.Actor(c),
+		Action:   action,
+		Target:   "outbox_messages",
+		TargetID: id.String(),
+		Note:     body.Note,
+	}
+	err = h.audit.Do(ctx, entry, func(tx *sql.Tx) error {
+		return apply(ctx, tx, id)
+	})
+	if err != nil {
+		return h.storeError(c, err)
+	}
+
+	msg, err := h.store.Get(ctx, id)
+	if err != nil {
+		return h.storeError(c, err)
+	}
+	return c.JSON(http.StatusOK, msg)
+}
+
+func (h *Handler) storeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrInvalidState):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to access outbox"})
+	}
+}
@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSent    Status = "sent"
+	// StatusFailed — публикация не удалась, следующая попытка запланирована на NextAttemptAt.
+	StatusFailed Status = "failed"
+	// StatusDead — попытки исчерпаны, сообщение больше не публикуется автоматически.
+	StatusDead Status = "dead"
+	// StatusDiscarded — оператор отказался от публикации сообщения (см. журнал admin_audit_log).
+	StatusDiscarded Status = "discarded"
+)
+
+// Message — строка таблицы outbox_messages: сообщение, которое должно быть
+// опубликовано в брокер после фиксации бизнес-транзакции.
+type Message struct {
+	ID         uuid.UUID `json:"id"`
+	MessageID  string    `json:"message_id"`
+	Exchange   string    `json:"exchange"`
+	RoutingKey string    `json:"routing_key"`
+	// Type — тип сообщения (см. pkg/contracts), публикуется в свойстве Type; по нему получатель выбирает обработчик.
+	Type       string          `json:"type,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	Headers    json.RawMessage `json:"headers,omitempty"`
+	Status     Status          `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	SentAt     *time.Time      `json:"sent_at,omitempty"`
+	Error      *string         `json:"error,omitempty"`
+	RetryCount int             `json:"retry_count"`
+	// NextAttemptAt — время, раньше которого сообщение не будет опубликовано.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// AggregateID — необязательный идентификатор агрегата (заказа, счета). Сообщения одного
+	// агрегата публикуются в порядке Seq, каждое — только после отправки предыдущих.
+	AggregateID string `json:"aggregate_id,omitempty"`
+	// Seq — порядковый номер записи в outbox, назначается базой данных.
+	Seq int64 `json:"seq"`
+}
+
+// NewMessage сериализует payload и готовит сообщение типа messageType к записи в outbox
+// с новым идентификатором, который получит и публикуемое сообщение.
+func NewMessage(exchange, routingKey, messageType string, payload any) (*Message, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	now := time.Now()
+	return &Message{
+		ID:            uuid.New(),
+		MessageID:     uuid.New().String(),
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		Type:          messageType,
+		Payload:       body,
+		Headers:       json.RawMessage(`{}`),
+		Status:        StatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// AggregateHeader — заголовок AMQP с AggregateID. По нему получатель сохраняет порядок
+// обработки сообщений одного агрегата (см. inbox.Store.Save).
+const AggregateHeader = "x-aggregate-id"
+
+// AggregateFromHeaders возвращает AggregateID из заголовков полученного сообщения или пустую строку.
+func AggregateFromHeaders(headers amqp.Table) string {
+	aggregateID, _ := headers[AggregateHeader].(string)
+	return aggregateID
+}
+
+// ForAggregate привязывает сообщение к агрегату для упорядоченной доставки.
+func (m *Message) ForAggregate(aggregateID string) *Message {
+	m.AggregateID = aggregateID
+	return m
+}
+
+// Publishing собирает AMQP-сообщение из сохраненной строки: тело, идентификатор и заголовки
+// берутся из outbox, поэтому повторная публикация дает то же сообщение и получатель
+// может отбросить дубль по MessageId.
+func (m Message) Publishing() (amqp.Publishing, error) {
+	headers, err := decodeHeaders(m.Headers)
+	if err != nil {
+		return amqp.Publishing{}, err
+	}
+	if m.AggregateID != "" {
+		if headers == nil {
+			headers = amqp.Table{}
+		}
+		headers[AggregateHeader] = m.AggregateID
+	}
+
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		Body:         m.Payload,
+		MessageId:    m.MessageID,
+		Type:         m.Type,
+		Timestamp:    m.CreatedAt,
+		DeliveryMode: amqp.Persistent,
+		Headers:      headers,
+	}, nil
+}
+
+func decodeHeaders(raw json.RawMessage) (amqp.Table, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var headers map[string]any
+	if err := json.Unmarshal(raw, &headers); err != nil {
+		return nil, fmt.Errorf("invalid outbox headers: %w", err)
+	}
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return toTable(headers), nil
+}
+
+// toTable приводит вложенные JSON-объекты к amqp.Table, иначе брокер отклонит заголовки.
+func toTable(values map[string]any) amqp.Table {
+	table := make(amqp.Table, len(values))
+	for key, value := range values {
+		table[key] = toAMQPValue(value)
+	}
+	return table
+}
+
+func toAMQPValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return toTable(v)
+	case []any:
+		for i := range v {
+			v[i] = toAMQPValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
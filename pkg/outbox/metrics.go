@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics — метрики Prometheus для relay.
+type Metrics struct {
+	published     *prometheus.CounterVec
+	failed        *prometheus.CounterVec
+	dead          *prometheus.CounterVec
+	latency       prometheus.Histogram
+	batchDuration prometheus.Histogram
+}
+
+// NewMetrics регистрирует метрики relay в reg с меткой service.
+func NewMetrics(service string, reg prometheus.Registerer) *Metrics {
+	labels := prometheus.Labels{"service": service}
+
+	m := &Metrics{
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "outbox_messages_published_total",
+			Help:        "Outbox messages successfully published to the broker.",
+			ConstLabels: labels,
+		}, []string{"exchange", "routing_key"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "outbox_publish_failures_total",
+			Help:        "Failed attempts to publish outbox messages.",
+			ConstLabels: labels,
+		}, []string{"exchange", "routing_key"}),
+		dead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "outbox_dead_messages_total",
+			Help:        "Outbox messages moved to the dead status after exhausting publish attempts.",
+			ConstLabels: labels,
+		}, []string{"exchange", "routing_key"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "outbox_delivery_latency_seconds",
+			Help:        "Time between writing a message to the outbox and publishing it.",
+			ConstLabels: labels,
+			Buckets:     []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+		batchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "outbox_batch_duration_seconds",
+			Help:        "Time spent processing one outbox batch.",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}),
+	}
+
+	reg.MustRegister(m.published, m.failed, m.dead, m.latency, m.batchDuration)
+	return m
+}
+
+// Hooks возвращает обратные вызовы relay, обновляющие метрики.
+func (m *Metrics) Hooks() Hooks {
+	return Hooks{
+		OnPublished: func(msg Message, latency time.Duration) {
+			m.published.WithLabelValues(msg.Exchange, msg.RoutingKey).Inc()
+			m.latency.Observe(latency.Seconds())
+		},
+		OnFailed: func(msg Message, err error) {
+			m.failed.WithLabelValues(msg.Exchange, msg.RoutingKey).Inc()
+		},
+		OnDead: func(msg Message) {
+			m.dead.WithLabelValues(msg.Exchange, msg.RoutingKey).Inc()
+		},
+		OnBatch: func(size int, duration time.Duration) {
+			m.batchDuration.Observe(duration.Seconds())
+		},
+	}
+}
+
+// statusCollector при каждом сборе метрик считает сообщения в таблице по статусам,
+// поэтому gauge outbox_messages{status="dead"} показывает текущее число мертвых сообщений
+// независимо от того, какой экземпляр их пометил и сколько раз сервис перезапускался.
+type statusCollector struct {
+	store Store
+	desc  *prometheus.Desc
+}
+
+// RegisterStatusGauge регистрирует gauge outbox_messages с числом сообщений в каждом статусе.
+func RegisterStatusGauge(service string, reg prometheus.Registerer, store Store) {
+	reg.MustRegister(&statusCollector{
+		store: store,
+		desc: prometheus.NewDesc(
+			"outbox_messages",
+			"Outbox messages by status.",
+			[]string{"status"},
+			prometheus.Labels{"service": service},
+		),
+	})
+}
+
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts, err := c.store.CountByStatus(ctx)
+	if err != nil {
+		log.Printf("Failed to count outbox messages: %v", err)
+		return
+	}
+
+	// Все статусы выводятся всегда, чтобы алерт на dead > 0 не зависел от появления ряда
+	for _, status := range []Status{StatusPending, StatusSent, StatusFailed, StatusDead, StatusDiscarded} {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
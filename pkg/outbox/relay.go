@@ -0,0 +1,181 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sd_hw4/pkg/backoff"
+	"sd_hw4/pkg/tunables"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Publisher отправляет сообщение в указанный exchange с указанным routing key.
+// Реализуется messaging.Connection.
+type Publisher interface {
+	Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error
+}
+
+// Hooks — необязательные обратные вызовы для метрик и наблюдения за relay.
+// Любое поле может быть nil.
+type Hooks struct {
+	// OnPublished вызывается после успешной публикации; latency — время от записи в outbox до отправки.
+	OnPublished func(msg Message, latency time.Duration)
+	// OnFailed вызывается, если сообщение не удалось опубликовать.
+	OnFailed func(msg Message, err error)
+	// OnDead вызывается, когда попытки публикации сообщения исчерпаны.
+	OnDead func(msg Message)
+	// OnBatch вызывается после обработки каждой непустой пачки.
+	OnBatch func(size int, duration time.Duration)
+}
+
+// RelayOptions — параметры relay.
+type RelayOptions struct {
+	// Owner — идентификатор экземпляра сервиса, от имени которого захватываются строки.
+	Owner string
+	// Lease — срок аренды захваченной пачки. Должен с запасом превышать время публикации пачки:
+	// по истечении аренды строки может забрать другой экземпляр.
+	Lease time.Duration
+	// Retry определяет задержки между попытками и их предельное число.
+	Retry backoff.Policy
+	// Wake будит relay вне очереди, например по уведомлению о новой строке (см. NotifyChannel).
+	// Может быть nil — тогда relay только опрашивает таблицу.
+	Wake  <-chan struct{}
+	Hooks Hooks
+}
+
+// Relay переносит сообщения из outbox в брокер. Несколько экземпляров relay
+// (реплики сервиса) могут работать с одной таблицей одновременно.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	tunables  *tunables.Processor
+	owner     string
+	lease     time.Duration
+	retry     backoff.Policy
+	wake      <-chan struct{}
+	hooks     Hooks
+}
+
+func NewRelay(store Store, publisher Publisher, tunables *tunables.Processor, opts RelayOptions) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		tunables:  tunables,
+		owner:     opts.Owner,
+		lease:     opts.Lease,
+		retry:     opts.Retry,
+		wake:      opts.Wake,
+		hooks:     opts.Hooks,
+	}
+}
+
+// Run запускает цикл публикации; завершается при отмене ctx.
+func (r *Relay) Run(ctx context.Context) {
+	r.tunables.Run(ctx, r.wake, r.ProcessBatch)
+	log.Println("Outbox relay stopped")
+}
+
+// ProcessBatch публикует ожидающие сообщения пачками по batchSize.
+// Следующая пачка забирается сразу, если текущая была полной или в ней были сообщения агрегатов:
+// за одну пачку публикуется не больше одного сообщения агрегата, и его последователи
+// становятся доступны только после отправки.
+func (r *Relay) ProcessBatch(ctx context.Context, batchSize int) {
+	for ctx.Err() == nil {
+		claimed, published, ordered := r.processBatch(ctx, batchSize)
+		if published == 0 || (claimed < batchSize && !ordered) {
+			return
+		}
+	}
+}
+
+// processBatch возвращает число захваченных и опубликованных сообщений и признак того,
+// что среди опубликованных были сообщения агрегатов.
+func (r *Relay) processBatch(ctx context.Context, batchSize int) (claimed, published int, ordered bool) {
+	started := time.Now()
+
+	messages, err := r.store.Claim(ctx, r.owner, r.lease, batchSize)
+	if err != nil {
+		log.Printf("Failed to claim pending outbox messages: %v", err)
+		return 0, 0, false
+	}
+	if len(messages) == 0 {
+		return 0, 0, false
+	}
+
+	// Оставшиеся сообщения не публикуются после истечения аренды: их уже мог забрать другой экземпляр
+	leaseDeadline := started.Add(r.lease)
+
+	for _, msg := range messages {
+		if time.Now().After(leaseDeadline) {
+			log.Printf("Outbox lease expired, leaving %s for another relay", msg.MessageID)
+			break
+		}
+
+		if !r.deliver(ctx, msg) {
+			continue
+		}
+		published++
+		if msg.AggregateID != "" {
+			ordered = true
+		}
+	}
+
+	if r.hooks.OnBatch != nil {
+		r.hooks.OnBatch(len(messages), time.Since(started))
+	}
+	return len(messages), published, ordered
+}
+
+// deliver публикует захваченное сообщение и отмечает результат; возвращает true, если сообщение отправлено.
+func (r *Relay) deliver(ctx context.Context, msg Message) bool {
+	if err := r.publish(ctx, msg); err != nil {
+		log.Printf("Failed to publish outbox message %s: %v", msg.MessageID, err)
+		if r.hooks.OnFailed != nil {
+			r.hooks.OnFailed(msg, err)
+		}
+		r.fail(ctx, msg, err)
+		return false
+	}
+
+	if err := r.store.MarkSent(ctx, msg.ID, r.owner); err != nil {
+		// Сообщение уже в брокере и будет отправлено повторно; получатель отбросит дубль по MessageId.
+		log.Printf("Failed to mark outbox message %s as sent: %v", msg.MessageID, err)
+		return false
+	}
+	if r.hooks.OnPublished != nil {
+		r.hooks.OnPublished(msg, time.Since(msg.CreatedAt))
+	}
+	return true
+}
+
+// fail планирует повторную попытку или, если попытки исчерпаны, переводит сообщение в dead.
+func (r *Relay) fail(ctx context.Context, msg Message, publishErr error) {
+	attempts := msg.RetryCount + 1
+
+	if r.retry.Exhausted(attempts) {
+		if err := r.store.MarkDead(ctx, msg.ID, r.owner, publishErr.Error()); err != nil {
+			log.Printf("Failed to mark outbox message %s as dead: %v", msg.MessageID, err)
+			return
+		}
+		log.Printf("Outbox message %s is dead after %d attempts", msg.MessageID, attempts)
+		if r.hooks.OnDead != nil {
+			r.hooks.OnDead(msg)
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(r.retry.Delay(attempts))
+	if err := r.store.MarkFailed(ctx, msg.ID, r.owner, publishErr.Error(), retryAt); err != nil {
+		log.Printf("Failed to mark outbox message %s as failed: %v", msg.MessageID, err)
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, msg Message) error {
+	publishing, err := msg.Publishing()
+	if err != nil {
+		return err
+	}
+	return r.publisher.Publish(ctx, msg.Exchange, msg.RoutingKey, publishing)
+}
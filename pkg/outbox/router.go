@@ -0,0 +1,23 @@
+package outbox
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Router — Publisher, который выбирает получателя по exchange сообщения. Так relay может
+// доставлять часть сообщений не в брокер (например, вебхуками), сохраняя общую очередь,
+// повторы и порядок записи outbox.
+type Router struct {
+	// Default получает сообщения всех exchange, которых нет в Exchanges (обычно брокер).
+	Default   Publisher
+	Exchanges map[string]Publisher
+}
+
+func (r Router) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	if publisher, ok := r.Exchanges[exchange]; ok {
+		return publisher.Publish(ctx, exchange, routingKey, msg)
+	}
+	return r.Default.Publish(ctx, exchange, routingKey, msg)
+}
@@ -0,0 +1,399 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sd_hw4/pkg/db"
+
+	"github.com/google/uuid"
+)
+
+// NotifyChannel — канал Postgres NOTIFY, в который триггер сообщает о новых строках outbox.
+const NotifyChannel = "outbox_messages"
+
+var (
+	ErrNotFound = errors.New("outbox message not found")
+	// ErrInvalidState — операция недопустима в текущем статусе сообщения.
+	ErrInvalidState = errors.New("outbox message is in a status that does not allow this operation")
+)
+
+// Filter — условия выборки сообщений; пустые поля не ограничивают выборку.
+type Filter struct {
+	Status     Status
+	Exchange   string
+	RoutingKey string
+	// AggregateID отбирает сообщения одного агрегата, например заказа.
+	AggregateID string
+	// OlderThan и NewerThan ограничивают возраст сообщения по created_at.
+	OlderThan time.Duration
+	NewerThan time.Duration
+	Limit     int
+}
+
+// ErrLeaseLost возвращается, если строка больше не принадлежит экземпляру:
+// аренда истекла и сообщение захватил другой relay.
+var ErrLeaseLost = errors.New("outbox lease lost")
+
+// Store — хранилище outbox-сообщений.
+type Store interface {
+	// Add записывает сообщение через exec — обычно транзакцию, в которой
+	// сохраняются бизнес-данные, чтобы сообщение и изменения зафиксировались атомарно.
+	Add(ctx context.Context, exec db.Executor, msg *Message) error
+	// Claim захватывает до limit ожидающих сообщений в аренду owner на время lease
+	// и возвращает их в порядке записи. Строки, захваченные другими экземплярами
+	// с неистекшей арендой, пропускаются. Из сообщений одного агрегата захватывается
+	// только самое раннее неотправленное.
+	Claim(ctx context.Context, owner string, lease time.Duration, limit int) ([]Message, error)
+	// ClaimOne захватывает сообщение id, если его можно публиковать сейчас; иначе возвращает nil
+	// (сообщение уже отправлено, захвачено другим экземпляром или ждет предшественников).
+	ClaimOne(ctx context.Context, id uuid.UUID, owner string, lease time.Duration) (*Message, error)
+	// MarkSent, MarkFailed и MarkDead снимают аренду; если строка уже не принадлежит owner,
+	// возвращается ErrLeaseLost.
+	MarkSent(ctx context.Context, id uuid.UUID, owner string) error
+	// MarkFailed фиксирует неудачную попытку и планирует следующую на retryAt.
+	MarkFailed(ctx context.Context, id uuid.UUID, owner, reason string, retryAt time.Time) error
+	// MarkDead фиксирует последнюю неудачную попытку и переводит сообщение в конечный статус dead.
+	MarkDead(ctx context.Context, id uuid.UUID, owner, reason string) error
+	// ListByStatus возвращает до limit сообщений с указанным статусом, начиная с самых старых.
+	ListByStatus(ctx context.Context, status Status, limit int) ([]Message, error)
+	// CountByStatus возвращает число сообщений в каждом статусе.
+	CountByStatus(ctx context.Context) (map[Status]int, error)
+
+	// List возвращает сообщения по фильтру, начиная с самых новых.
+	List(ctx context.Context, filter Filter) ([]Message, error)
+	Get(ctx context.Context, id uuid.UUID) (*Message, error)
+	// Requeue возвращает сообщение в статусе failed или dead к публикации с полным запасом попыток.
+	Requeue(ctx context.Context, exec db.Executor, id uuid.UUID) error
+	// Discard отменяет публикацию еще не отправленного сообщения.
+	Discard(ctx context.Context, exec db.Executor, id uuid.UUID) error
+	// DiscardUnsent отменяет публикацию всех еще не отправленных и не захваченных relay
+	// сообщений агрегата aggregateID с типом messageType и возвращает их число.
+	// Нужен, когда бизнес-операция делает отложенное сообщение ненужным (отмена заказа).
+	DiscardUnsent(ctx context.Context, exec db.Executor, aggregateID, messageType string) (int, error)
+}
+
+// PostgresStore — реализация Store поверх таблицы outbox_messages.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+const messageColumns = `id, message_id, exchange, routing_key, message_type, payload, headers, status, created_at, sent_at, error, retry_count, next_attempt_at, aggregate_id, seq`
+
+func (s *PostgresStore) Add(ctx context.Context, exec db.Executor, msg *Message) error {
+	if msg.ID == uuid.Nil {
+		msg.ID = uuid.New()
+	}
+	if msg.MessageID == "" {
+		msg.MessageID = uuid.New().String()
+	}
+	if msg.Status == "" {
+		msg.Status = StatusPending
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	if msg.NextAttemptAt.IsZero() {
+		msg.NextAttemptAt = msg.CreatedAt
+	}
+
+	query := `INSERT INTO outbox_messages
+		(id, message_id, exchange, routing_key, payload, headers, status, created_at, retry_count, next_attempt_at, aggregate_id, message_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''))`
+
+	var headers any
+	if len(msg.Headers) > 0 {
+		headers = []byte(msg.Headers)
+	}
+
+	_, err := exec.ExecContext(ctx, query,
+		msg.ID, msg.MessageID, msg.Exchange, msg.RoutingKey,
+		[]byte(msg.Payload), headers, msg.Status, msg.CreatedAt, msg.RetryCount, msg.NextAttemptAt, msg.AggregateID, msg.Type)
+	return err
+}
+
+// claimable — условие, при котором строку m можно захватить: сообщение ожидает публикации,
+// время очередной попытки наступило, аренда другого экземпляра не действует.
+// Сообщение агрегата захватывается, только если все его предшественники (меньший seq)
+// отправлены или отброшены оператором. Предшественник, ожидающий повтора или мертвый,
+// задерживает остальные сообщения своего агрегата, другие агрегаты это не затрагивает.
+const claimable = `m.status IN ('pending', 'failed')
+	AND m.next_attempt_at <= now()
+	AND (m.lease_until IS NULL OR m.lease_until < now())
+	AND (m.aggregate_id IS NULL OR NOT EXISTS (
+		SELECT 1 FROM outbox_messages p
+		WHERE p.aggregate_id = m.aggregate_id
+			AND p.seq < m.seq
+			AND p.status IN ('pending', 'failed', 'dead')
+	))`
+
+// Claim выбирает строки с FOR UPDATE SKIP LOCKED, поэтому параллельные экземпляры
+// не блокируют друг друга и не получают одни и те же строки, а проставленная аренда
+// защищает строки и после фиксации захвата. Аренда упавшего экземпляра истекает,
+// и его строки снова становятся доступны. Неудачно опубликованные сообщения
+// захватываются повторно только после наступления next_attempt_at.
+func (s *PostgresStore) Claim(ctx context.Context, owner string, lease time.Duration, limit int) ([]Message, error) {
+	query := `UPDATE outbox_messages
+		SET claimed_by = $1, lease_until = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT m.id FROM outbox_messages m
+			WHERE ` + claimable + `
+			ORDER BY m.seq ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + messageColumns
+
+	rows, err := s.db.QueryContext(ctx, query, owner, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Seq < messages[j].Seq
+	})
+	return messages, nil
+}
+
+// ClaimOne захватывает одно сообщение по тем же правилам, что и Claim.
+func (s *PostgresStore) ClaimOne(ctx context.Context, id uuid.UUID, owner string, lease time.Duration) (*Message, error) {
+	query := `UPDATE outbox_messages
+		SET claimed_by = $1, lease_until = now() + make_interval(secs => $2)
+		WHERE id = (
+			SELECT m.id FROM outbox_messages m
+			WHERE m.id = $3 AND ` + claimable + `
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + messageColumns
+
+	rows, err := s.db.QueryContext(ctx, query, owner, lease.Seconds(), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+func (s *PostgresStore) MarkSent(ctx context.Context, id uuid.UUID, owner string) error {
+	query := `UPDATE outbox_messages
+		SET status = $1, sent_at = $2, error = NULL, claimed_by = NULL, lease_until = NULL
+		WHERE id = $3 AND claimed_by = $4`
+	result, err := s.db.ExecContext(ctx, query, StatusSent, time.Now(), id, owner)
+	return checkLease(result, err)
+}
+
+func (s *PostgresStore) MarkFailed(ctx context.Context, id uuid.UUID, owner, reason string, retryAt time.Time) error {
+	query := `UPDATE outbox_messages
+		SET status = $1, error = $2, retry_count = retry_count + 1, next_attempt_at = $3,
+			claimed_by = NULL, lease_until = NULL
+		WHERE id = $4 AND claimed_by = $5`
+	result, err := s.db.ExecContext(ctx, query, StatusFailed, reason, retryAt, id, owner)
+	return checkLease(result, err)
+}
+
+func (s *PostgresStore) MarkDead(ctx context.Context, id uuid.UUID, owner, reason string) error {
+	query := `UPDATE outbox_messages
+		SET status = $1, error = $2, retry_count = retry_count + 1, claimed_by = NULL, lease_until = NULL
+		WHERE id = $3 AND claimed_by = $4`
+	result, err := s.db.ExecContext(ctx, query, StatusDead, reason, id, owner)
+	return checkLease(result, err)
+}
+
+func (s *PostgresStore) ListByStatus(ctx context.Context, status Status, limit int) ([]Message, error) {
+	query := `SELECT ` + messageColumns + `
+		FROM outbox_messages WHERE status = $1 ORDER BY created_at ASC LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (s *PostgresStore) CountByStatus(ctx context.Context) (map[Status]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status, count(*) FROM outbox_messages GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[Status]int)
+	for rows.Next() {
+		var status Status
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *PostgresStore) List(ctx context.Context, filter Filter) ([]Message, error) {
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+arg(filter.Status))
+	}
+	if filter.Exchange != "" {
+		conditions = append(conditions, "exchange = "+arg(filter.Exchange))
+	}
+	if filter.RoutingKey != "" {
+		conditions = append(conditions, "routing_key = "+arg(filter.RoutingKey))
+	}
+	if filter.AggregateID != "" {
+		conditions = append(conditions, "aggregate_id = "+arg(filter.AggregateID))
+	}
+	if filter.OlderThan > 0 {
+		conditions = append(conditions, "created_at < now() - make_interval(secs => "+arg(filter.OlderThan.Seconds())+")")
+	}
+	if filter.NewerThan > 0 {
+		conditions = append(conditions, "created_at >= now() - make_interval(secs => "+arg(filter.NewerThan.Seconds())+")")
+	}
+
+	query := `SELECT ` + messageColumns + ` FROM outbox_messages`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC LIMIT ` + arg(filter.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id uuid.UUID) (*Message, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+messageColumns+` FROM outbox_messages WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrNotFound
+	}
+	return &messages[0], nil
+}
+
+func (s *PostgresStore) Requeue(ctx context.Context, exec db.Executor, id uuid.UUID) error {
+	query := `UPDATE outbox_messages
+		SET status = $1, retry_count = 0, next_attempt_at = now(), claimed_by = NULL, lease_until = NULL
+		WHERE id = $2 AND status IN ($3, $4)`
+	result, err := exec.ExecContext(ctx, query, StatusPending, id, StatusFailed, StatusDead)
+	return checkUpdated(ctx, exec, id, result, err)
+}
+
+// Discard не трогает строки, захваченные relay: публикация могла уже начаться.
+func (s *PostgresStore) Discard(ctx context.Context, exec db.Executor, id uuid.UUID) error {
+	query := `UPDATE outbox_messages
+		SET status = $1
+		WHERE id = $2 AND status IN ($3, $4, $5) AND (lease_until IS NULL OR lease_until < now())`
+	result, err := exec.ExecContext(ctx, query, StatusDiscarded, id, StatusPending, StatusFailed, StatusDead)
+	return checkUpdated(ctx, exec, id, result, err)
+}
+
+func (s *PostgresStore) DiscardUnsent(ctx context.Context, exec db.Executor, aggregateID, messageType string) (int, error) {
+	query := `UPDATE outbox_messages
+		SET status = $1
+		WHERE aggregate_id = $2 AND message_type = $3 AND status IN ($4, $5, $6)
+			AND (lease_until IS NULL OR lease_until < now())`
+	result, err := exec.ExecContext(ctx, query, StatusDiscarded, aggregateID, messageType, StatusPending, StatusFailed, StatusDead)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// checkUpdated отличает отсутствующее сообщение от сообщения в неподходящем статусе.
+func checkUpdated(ctx context.Context, exec db.Executor, id uuid.UUID, result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := exec.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM outbox_messages WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrInvalidState
+}
+
+func checkLease(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func scanMessages(rows *sql.Rows) ([]Message, error) {
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var headers []byte
+		var aggregateID, messageType sql.NullString
+		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.Exchange, &msg.RoutingKey, &messageType,
+			&msg.Payload, &headers, &msg.Status, &msg.CreatedAt, &msg.SentAt, &msg.Error, &msg.RetryCount, &msg.NextAttemptAt,
+			&aggregateID, &msg.Seq)
+		if err != nil {
+			return nil, err
+		}
+		msg.Headers = headers
+		msg.AggregateID = aggregateID.String
+		msg.Type = messageType.String
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
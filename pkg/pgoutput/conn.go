@@ -0,0 +1,192 @@
+// Package pgoutput — минимальный клиент логической репликации Postgres с плагином pgoutput:
+// создание слота, запуск потока, разбор изменений и подтверждение обработанной позиции.
+package pgoutput
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+// XLogData — порция WAL с одним сообщением pgoutput.
+type XLogData struct {
+	WALStart     LSN
+	ServerWALEnd LSN
+	// Message — результат Decoder.Decode: Begin, Commit, *Relation, Insert, Update или Other.
+	Message any
+}
+
+// Keepalive — периодическое сообщение сервера; при ReplyRequested клиент должен
+// сразу отправить статус, иначе сервер разорвет соединение по wal_sender_timeout.
+type Keepalive struct {
+	ServerWALEnd   LSN
+	ReplyRequested bool
+}
+
+// namePattern ограничивает имена слотов и публикаций: они подставляются в команды
+// протокола репликации, которые не поддерживают параметры.
+var namePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// ValidName сообщает, можно ли использовать name как имя слота или публикации.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Conn — соединение в режиме логической репликации.
+type Conn struct {
+	conn    *pgconn.PgConn
+	decoder *Decoder
+}
+
+// Connect открывает соединение репликации к базе из dsn (URL или key=value).
+func Connect(ctx context.Context, dsn string) (*Conn, error) {
+	cfg, err := pgconn.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replication DSN: %w", err)
+	}
+	cfg.RuntimeParams["replication"] = "database"
+
+	conn, err := pgconn.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replication connection: %w", err)
+	}
+	return &Conn{conn: conn, decoder: NewDecoder()}, nil
+}
+
+func (c *Conn) Close(ctx context.Context) error {
+	return c.conn.Close(ctx)
+}
+
+// EnsureSlot создает логический слот с плагином pgoutput, если его нет.
+// Возвращает true, если слот создан сейчас.
+func (c *Conn) EnsureSlot(ctx context.Context, slot string) (bool, error) {
+	if !ValidName(slot) {
+		return false, fmt.Errorf("invalid replication slot name %q", slot)
+	}
+
+	results, err := c.conn.Exec(ctx, "SELECT 1 FROM pg_replication_slots WHERE slot_name = '"+slot+"'").ReadAll()
+	if err != nil {
+		return false, fmt.Errorf("failed to look up replication slot: %w", err)
+	}
+	if len(results) > 0 && len(results[0].Rows) > 0 {
+		return false, nil
+	}
+
+	_, err = c.conn.Exec(ctx, "CREATE_REPLICATION_SLOT "+slot+" LOGICAL pgoutput NOEXPORT_SNAPSHOT").ReadAll()
+	if err != nil {
+		return false, fmt.Errorf("failed to create replication slot %s: %w", slot, err)
+	}
+	return true, nil
+}
+
+// Start запускает поток изменений публикации начиная с позиции from. Сервер начинает
+// не раньше подтвержденной позиции слота, поэтому from = 0 означает «с последнего подтверждения».
+func (c *Conn) Start(ctx context.Context, slot, publication string, from LSN) error {
+	if !ValidName(slot) || !ValidName(publication) {
+		return fmt.Errorf("invalid replication slot %q or publication %q name", slot, publication)
+	}
+
+	query := fmt.Sprintf("START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names '%s')",
+		slot, from, publication)
+	c.conn.Frontend().Send(&pgproto3.Query{String: query})
+	if err := c.conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("failed to start replication: %w", err)
+	}
+
+	for {
+		msg, err := c.conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to start replication: %w", err)
+		}
+		switch m := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			return nil
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(m)
+		case *pgproto3.NoticeResponse, *pgproto3.ParameterStatus:
+		default:
+			return fmt.Errorf("unexpected message %T while starting replication", msg)
+		}
+	}
+}
+
+// Receive ждет следующее сообщение потока: XLogData или Keepalive.
+// Истечение ctx проверяется через Timeout.
+func (c *Conn) Receive(ctx context.Context) (any, error) {
+	for {
+		msg, err := c.conn.ReceiveMessage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		switch m := msg.(type) {
+		case *pgproto3.CopyData:
+			return c.decodeCopyData(m.Data)
+		case *pgproto3.ErrorResponse:
+			return nil, pgconn.ErrorResponseToPgError(m)
+		case *pgproto3.CopyDone:
+			return nil, errors.New("replication stream closed by server")
+		}
+	}
+}
+
+func (c *Conn) decodeCopyData(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, errShortMessage
+	}
+	r := &reader{buf: data[1:]}
+
+	switch data[0] {
+	case 'k':
+		msg := Keepalive{ServerWALEnd: LSN(r.uint64())}
+		r.int64() // время сервера
+		msg.ReplyRequested = r.byte() == 1
+		return msg, r.err
+	case 'w':
+		msg := XLogData{WALStart: LSN(r.uint64()), ServerWALEnd: LSN(r.uint64())}
+		r.int64() // время сервера
+		if r.err != nil {
+			return nil, r.err
+		}
+		decoded, err := c.decoder.Decode(r.buf)
+		if err != nil {
+			return nil, err
+		}
+		msg.Message = decoded
+		return msg, nil
+	default:
+		return nil, fmt.Errorf("unexpected replication message %q", data[0])
+	}
+}
+
+// SendStandbyStatus подтверждает, что все изменения до lsn обработаны:
+// сервер может освободить WAL до этой позиции, а после переподключения начнет с нее.
+func (c *Conn) SendStandbyStatus(lsn LSN) error {
+	data := make([]byte, 0, 34)
+	data = append(data, 'r')
+	data = binary.BigEndian.AppendUint64(data, uint64(lsn)) // записано
+	data = binary.BigEndian.AppendUint64(data, uint64(lsn)) // сохранено
+	data = binary.BigEndian.AppendUint64(data, uint64(lsn)) // применено
+	data = binary.BigEndian.AppendUint64(data, uint64(pgMicros(time.Now())))
+	data = append(data, 0)
+
+	encoded, err := (&pgproto3.CopyData{Data: data}).Encode(nil)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.Frontend().SendUnbufferedEncodedCopyData(encoded); err != nil {
+		return fmt.Errorf("failed to send standby status: %w", err)
+	}
+	return nil
+}
+
+// Timeout сообщает, что Receive завершился по истечении ctx, а не из-за ошибки соединения.
+func Timeout(err error) bool {
+	return pgconn.Timeout(err)
+}
@@ -0,0 +1,254 @@
+package pgoutput
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Сообщения логического декодирования pgoutput (протокол версии 1), которые нужны relay.
+// Остальные (Origin, Type, Truncate, Message) разбираются как Other и пропускаются.
+// Формат: https://www.postgresql.org/docs/current/protocol-logicalrep-message-formats.html
+
+// Begin открывает транзакцию.
+type Begin struct {
+	FinalLSN   LSN
+	CommitTime time.Time
+	XID        uint32
+}
+
+// Commit завершает транзакцию; EndLSN — позиция, которую можно подтверждать после обработки транзакции.
+type Commit struct {
+	CommitLSN  LSN
+	EndLSN     LSN
+	CommitTime time.Time
+}
+
+// Column — столбец таблицы в сообщении Relation.
+type Column struct {
+	Name    string
+	TypeOID uint32
+}
+
+// Relation описывает таблицу; сервер отправляет его перед первым изменением таблицы в сессии
+// и после изменения ее структуры.
+type Relation struct {
+	ID        uint32
+	Namespace string
+	Name      string
+	Columns   []Column
+}
+
+// Row — изменение строки: значения столбцов в текстовом формате, nil для NULL
+// и для не изменившихся TOAST-значений.
+type Row struct {
+	Relation *Relation
+	Values   map[string]*string
+}
+
+// Insert — вставка строки.
+type Insert struct{ Row }
+
+// Update — изменение строки, Values содержат новую версию.
+type Update struct{ Row }
+
+// Other — сообщение, которое relay не обрабатывает.
+type Other struct {
+	Type byte
+}
+
+var errShortMessage = errors.New("pgoutput: message is too short")
+
+// Decoder разбирает поток pgoutput. Хранит описания таблиц из сообщений Relation,
+// поэтому для одного потока репликации нужен один Decoder.
+type Decoder struct {
+	relations map[uint32]*Relation
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{relations: make(map[uint32]*Relation)}
+}
+
+// Decode разбирает одно сообщение pgoutput.
+func (d *Decoder) Decode(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, errShortMessage
+	}
+	r := &reader{buf: data[1:]}
+
+	switch data[0] {
+	case 'B':
+		msg := Begin{FinalLSN: LSN(r.uint64()), CommitTime: pgTime(r.int64()), XID: r.uint32()}
+		return msg, r.err
+	case 'C':
+		r.byte() // flags, не используются
+		msg := Commit{CommitLSN: LSN(r.uint64()), EndLSN: LSN(r.uint64()), CommitTime: pgTime(r.int64())}
+		return msg, r.err
+	case 'R':
+		return d.decodeRelation(r)
+	case 'I':
+		row, err := d.decodeInsert(r)
+		return Insert{row}, err
+	case 'U':
+		row, err := d.decodeUpdate(r)
+		return Update{row}, err
+	default:
+		return Other{Type: data[0]}, nil
+	}
+}
+
+func (d *Decoder) decodeRelation(r *reader) (any, error) {
+	rel := &Relation{ID: r.uint32(), Namespace: r.string(), Name: r.string()}
+	r.byte() // replica identity
+	count := int(r.uint16())
+	for i := 0; i < count && r.err == nil; i++ {
+		r.byte() // флаги столбца (входит ли в ключ)
+		column := Column{Name: r.string(), TypeOID: r.uint32()}
+		r.uint32() // модификатор типа
+		rel.Columns = append(rel.Columns, column)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	d.relations[rel.ID] = rel
+	return rel, nil
+}
+
+// decodeUpdate пропускает необязательную старую версию строки ('K' или 'O') перед новой ('N').
+func (d *Decoder) decodeUpdate(r *reader) (Row, error) {
+	relationID := r.uint32()
+	rel, ok := d.relations[relationID]
+	if !ok {
+		return Row{}, fmt.Errorf("pgoutput: unknown relation %d", relationID)
+	}
+
+	kind := r.byte()
+	if kind == 'K' || kind == 'O' {
+		if _, err := decodeTuple(r, rel); err != nil {
+			return Row{}, err
+		}
+		kind = r.byte()
+	}
+	if kind != 'N' {
+		return Row{}, fmt.Errorf("pgoutput: unexpected tuple marker %q in update", kind)
+	}
+
+	values, err := decodeTuple(r, rel)
+	return Row{Relation: rel, Values: values}, err
+}
+
+func (d *Decoder) decodeInsert(r *reader) (Row, error) {
+	relationID := r.uint32()
+	rel, ok := d.relations[relationID]
+	if !ok {
+		return Row{}, fmt.Errorf("pgoutput: unknown relation %d", relationID)
+	}
+	if kind := r.byte(); kind != 'N' {
+		return Row{}, fmt.Errorf("pgoutput: unexpected tuple marker %q in insert", kind)
+	}
+
+	values, err := decodeTuple(r, rel)
+	return Row{Relation: rel, Values: values}, err
+}
+
+func decodeTuple(r *reader, rel *Relation) (map[string]*string, error) {
+	count := int(r.uint16())
+	if r.err == nil && count != len(rel.Columns) {
+		return nil, fmt.Errorf("pgoutput: relation %s has %d columns, tuple has %d", rel.Name, len(rel.Columns), count)
+	}
+
+	values := make(map[string]*string, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		switch kind := r.byte(); kind {
+		case 'n', 'u':
+			values[rel.Columns[i].Name] = nil
+		case 't':
+			value := string(r.bytes(int(r.uint32())))
+			values[rel.Columns[i].Name] = &value
+		default:
+			return nil, fmt.Errorf("pgoutput: unsupported column format %q", kind)
+		}
+	}
+	return values, r.err
+}
+
+// postgresEpoch — начало отсчета времени в протоколе репликации.
+var postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func pgTime(micros int64) time.Time {
+	return postgresEpoch.Add(time.Duration(micros) * time.Microsecond)
+}
+
+func pgMicros(t time.Time) int64 {
+	return t.Sub(postgresEpoch).Microseconds()
+}
+
+// reader последовательно читает поля сообщения; первая ошибка сохраняется в err,
+// последующие чтения возвращают нулевые значения.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.buf) < n {
+		r.err = errShortMessage
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *reader) int64() int64 {
+	return int64(r.uint64())
+}
+
+// string читает строку, завершенную нулевым байтом.
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, b := range r.buf {
+		if b == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+	r.err = errShortMessage
+	return ""
+}
@@ -0,0 +1,21 @@
+package pgoutput
+
+import (
+	"fmt"
+)
+
+// LSN — позиция в журнале предзаписи (WAL) Postgres.
+type LSN uint64
+
+// ParseLSN разбирает LSN в текстовом формате Postgres ("16/B374D848").
+func ParseLSN(s string) (LSN, error) {
+	var upper, lower uint32
+	if _, err := fmt.Sscanf(s, "%X/%X", &upper, &lower); err != nil {
+		return 0, fmt.Errorf("invalid LSN %q: %w", s, err)
+	}
+	return LSN(uint64(upper)<<32 | uint64(lower)), nil
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
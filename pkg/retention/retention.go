@@ -0,0 +1,337 @@
+// Package retention удаляет или архивирует обработанные строки служебных таблиц
+// (outbox, inbox, ключи идемпотентности, журнал вебхуков), чтобы они не росли бесконечно.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"sd_hw4/pkg/tunables"
+
+	"github.com/lib/pq"
+)
+
+type Mode string
+
+const (
+	// ModeKeep — строки не удаляются.
+	ModeKeep Mode = "keep"
+	// ModeDelete — строки старше MaxAge удаляются.
+	ModeDelete Mode = "delete"
+	// ModeArchive — строки старше MaxAge переносятся в таблицу <table>_archive,
+	// секционированную по месяцам created_at.
+	ModeArchive Mode = "archive"
+)
+
+// Policy — правило хранения для одной таблицы. Таблица должна иметь столбцы id и created_at.
+type Policy struct {
+	Table string
+	// Condition отбирает строки, которые больше не нужны (например, уже отправленные).
+	Condition string
+	Mode      Mode
+	MaxAge    time.Duration
+}
+
+// OutboxPolicy — правило для outbox_messages: очищаются отправленные и отброшенные оператором
+// сообщения, ожидающие и мертвые остаются.
+func OutboxPolicy(mode Mode, maxAge time.Duration) Policy {
+	return Policy{Table: "outbox_messages", Condition: "status IN ('sent', 'discarded')", Mode: mode, MaxAge: maxAge}
+}
+
+// InboxPolicy — правило для inbox_messages: очищаются только обработанные сообщения.
+// Пока строка есть в таблице, повторная доставка того же сообщения отбрасывается по message_id,
+// поэтому maxAge не должен быть меньше окна дедупликации.
+func InboxPolicy(mode Mode, maxAge time.Duration) Policy {
+	return Policy{Table: "inbox_messages", Condition: "processed = true", Mode: mode, MaxAge: maxAge}
+}
+
+// IdempotencyPolicy — правило для idempotency_keys: ключи старше ttl удаляются. Устаревший ключ
+// уже не защищает от повтора, поэтому хранить или архивировать его незачем.
+func IdempotencyPolicy(ttl time.Duration) Policy {
+	return Policy{Table: "idempotency_keys", Condition: "true", Mode: ModeDelete, MaxAge: ttl}
+}
+
+// WebhookDeliveryPolicy — правило для журнала доставок вебхуков webhook_deliveries: очищаются
+// завершенные доставки (доставленные и мертвые), ожидающие повтора остаются.
+func WebhookDeliveryPolicy(mode Mode, maxAge time.Duration) Policy {
+	return Policy{Table: "webhook_deliveries", Condition: "status IN ('delivered', 'dead')", Mode: mode, MaxAge: maxAge}
+}
+
+// Hooks — необязательные обратные вызовы для метрик.
+type Hooks struct {
+	// OnRemoved вызывается после каждой пачки удаленных или перенесенных в архив строк.
+	OnRemoved func(table string, mode Mode, rows int)
+}
+
+// Cleaner периодически применяет правила хранения. Строки удаляются пачками по batchSize,
+// каждая пачка — в отдельной короткой транзакции, поэтому блокировки не держатся долго
+// и не мешают relay и обработчикам inbox.
+type Cleaner struct {
+	db       *sql.DB
+	tunables *tunables.Processor
+	policies []Policy
+	hooks    Hooks
+
+	// archiveColumns — столбцы, переносимые в архив; определяются и сверяются с архивом при первом переносе
+	archiveColumns map[string][]string
+}
+
+func NewCleaner(db *sql.DB, tunables *tunables.Processor, hooks Hooks, policies ...Policy) *Cleaner {
+	return &Cleaner{
+		db:             db,
+		tunables:       tunables,
+		policies:       policies,
+		hooks:          hooks,
+		archiveColumns: make(map[string][]string),
+	}
+}
+
+// Run запускает очистку по расписанию; завершается при отмене ctx.
+func (c *Cleaner) Run(ctx context.Context) {
+	c.tunables.Run(ctx, nil, c.Cleanup)
+	log.Println("Retention cleaner stopped")
+}
+
+// Cleanup применяет все правила, пока не обработает все устаревшие строки.
+func (c *Cleaner) Cleanup(ctx context.Context, batchSize int) {
+	for _, policy := range c.policies {
+		if policy.Mode == ModeKeep {
+			continue
+		}
+
+		for ctx.Err() == nil {
+			removed, err := c.cleanupBatch(ctx, policy, batchSize)
+			if err != nil {
+				log.Printf("Retention for %s failed: %v", policy.Table, err)
+				break
+			}
+			if removed > 0 && c.hooks.OnRemoved != nil {
+				c.hooks.OnRemoved(policy.Table, policy.Mode, removed)
+			}
+			if removed < batchSize {
+				break
+			}
+		}
+	}
+}
+
+func (c *Cleaner) cleanupBatch(ctx context.Context, policy Policy, batchSize int) (int, error) {
+	switch policy.Mode {
+	case ModeDelete:
+		return c.deleteBatch(ctx, policy, batchSize)
+	case ModeArchive:
+		return c.archiveBatch(ctx, policy, batchSize)
+	default:
+		return 0, fmt.Errorf("unknown retention mode %q", policy.Mode)
+	}
+}
+
+// expired — условие отбора устаревших строк; возраст считается по часам базы данных,
+// как и значения created_at по умолчанию. Параметр $1 — MaxAge в секундах.
+func expired(policy Policy) string {
+	return policy.Condition + ` AND created_at < now() - make_interval(secs => $1)`
+}
+
+func (c *Cleaner) deleteBatch(ctx context.Context, policy Policy, batchSize int) (int, error) {
+	table := pq.QuoteIdentifier(policy.Table)
+	query := `DELETE FROM ` + table + ` WHERE id IN (
+		SELECT id FROM ` + table + `
+		WHERE ` + expired(policy) + `
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)`
+
+	result, err := c.db.ExecContext(ctx, query, policy.MaxAge.Seconds(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// archiveBatch переносит пачку строк в архив в одной транзакции: выбирает и блокирует строки,
+// создает недостающие месячные секции и перемещает строки через DELETE ... RETURNING.
+func (c *Cleaner) archiveBatch(ctx context.Context, policy Policy, batchSize int) (int, error) {
+	columns, err := c.ensureArchive(ctx, policy.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	table := pq.QuoteIdentifier(policy.Table)
+	rows, err := tx.QueryContext(ctx, `SELECT id::text, created_at FROM `+table+`
+		WHERE `+expired(policy)+`
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`, policy.MaxAge.Seconds(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	months := make(map[time.Time]bool)
+	for rows.Next() {
+		var id string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		months[monthStart(createdAt)] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	for month := range months {
+		if err := ensurePartition(ctx, tx, policy.Table, month); err != nil {
+			return 0, err
+		}
+	}
+
+	columnList := strings.Join(columns, ", ")
+	result, err := tx.ExecContext(ctx, `WITH moved AS (
+			DELETE FROM `+table+` WHERE id::text = ANY($1) RETURNING *
+		)
+		INSERT INTO `+pq.QuoteIdentifier(archiveTable(policy.Table))+` (`+columnList+`)
+		SELECT `+columnList+` FROM moved`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(moved), tx.Commit()
+}
+
+// ensureArchive при первом обращении создает секционированную архивную таблицу
+// по образцу исходной и возвращает столбцы, которые переносятся в архив. Архив, созданный
+// раньше, догоняет исходную таблицу: столбцы, добавленные в нее позже, добавляются в архив
+// (в уже перенесенных строках они пустые). Если тип столбца в архиве отличается от исходного,
+// перенос останавливается с ошибкой, чтобы данные не терялись и не искажались молча.
+func (c *Cleaner) ensureArchive(ctx context.Context, table string) ([]string, error) {
+	if columns, ok := c.archiveColumns[table]; ok {
+		return columns, nil
+	}
+
+	archive := archiveTable(table)
+	_, err := c.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+pq.QuoteIdentifier(archive)+
+		` (LIKE `+pq.QuoteIdentifier(table)+` INCLUDING DEFAULTS) PARTITION BY RANGE (created_at)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive table %s: %w", archive, err)
+	}
+
+	source, err := tableColumns(ctx, c.db, table)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := tableColumns(ctx, c.db, archive)
+	if err != nil {
+		return nil, err
+	}
+	archivedTypes := make(map[string]string, len(archived))
+	for _, column := range archived {
+		archivedTypes[column.name] = column.dataType
+	}
+
+	columns := make([]string, 0, len(source))
+	for _, column := range source {
+		dataType, ok := archivedTypes[column.name]
+		switch {
+		case !ok:
+			// Столбец добавляется без NOT NULL: в архиве уже есть строки без этого значения
+			_, err := c.db.ExecContext(ctx, `ALTER TABLE `+pq.QuoteIdentifier(archive)+
+				` ADD COLUMN IF NOT EXISTS `+pq.QuoteIdentifier(column.name)+` `+column.dataType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add column %s to archive table %s: %w", column.name, archive, err)
+			}
+		case dataType != column.dataType:
+			return nil, fmt.Errorf("column %s of archive table %s has type %s, but %s has type %s",
+				column.name, archive, dataType, table, column.dataType)
+		}
+		columns = append(columns, pq.QuoteIdentifier(column.name))
+	}
+
+	c.archiveColumns[table] = columns
+	return columns, nil
+}
+
+type tableColumn struct {
+	name     string
+	dataType string
+}
+
+// tableColumns возвращает столбцы таблицы в порядке объявления с типами в виде SQL (format_type).
+func tableColumns(ctx context.Context, db *sql.DB, table string) ([]tableColumn, error) {
+	rows, err := db.QueryContext(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, pq.QuoteIdentifier(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []tableColumn
+	for rows.Next() {
+		var column tableColumn
+		if err := rows.Scan(&column.name, &column.dataType); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+	return columns, nil
+}
+
+func ensurePartition(ctx context.Context, tx *sql.Tx, table string, month time.Time) error {
+	archive := archiveTable(table)
+	partition := fmt.Sprintf("%s_%s", archive, month.Format("200601"))
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)`,
+		pq.QuoteIdentifier(partition), pq.QuoteIdentifier(archive),
+		pq.QuoteLiteral(month.Format(time.DateOnly)), pq.QuoteLiteral(month.AddDate(0, 1, 0).Format(time.DateOnly)),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create archive partition %s: %w", partition, err)
+	}
+	return nil
+}
+
+// Tables возвращает таблицы правил и их архивы — для метрик размера.
+func Tables(policies ...Policy) []string {
+	var tables []string
+	for _, policy := range policies {
+		tables = append(tables, policy.Table, archiveTable(policy.Table))
+	}
+	return tables
+}
+
+func archiveTable(table string) string {
+	return table + "_archive"
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
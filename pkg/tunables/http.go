@@ -0,0 +1,105 @@
+package tunables
+
+import (
+	"net/http"
+	"time"
+
+	"sd_hw4/pkg/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler — административный API для просмотра и изменения параметров обработчиков.
+type Handler struct {
+	registry *Registry
+}
+
+func NewHandler(registry *Registry) *Handler {
+	return &Handler{registry: registry}
+}
+
+// RegisterRoutes регистрирует маршруты в группе (обычно /admin):
+//
+//	GET   /processors              — действующие параметры всех обработчиков
+//	GET   /processors/:name        — параметры одного обработчика
+//	PATCH /processors/:name        — изменение batch_size, poll_interval, paused
+//	POST  /processors/:name/pause  — приостановка
+//	POST  /processors/:name/resume — возобновление
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/processors", h.List)
+	g.GET("/processors/:name", h.Get)
+	g.PATCH("/processors/:name", h.Update)
+	g.POST("/processors/:name/pause", h.Pause)
+	g.POST("/processors/:name/resume", h.Resume)
+}
+
+func (h *Handler) List(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.registry.Snapshot())
+}
+
+func (h *Handler) Get(c echo.Context) error {
+	p, ok := h.registry.Get(c.Param("name"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "processor not found"})
+	}
+	return c.JSON(http.StatusOK, p.Settings())
+}
+
+func (h *Handler) Update(c echo.Context) error {
+	p, ok := h.registry.Get(c.Param("name"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "processor not found"})
+	}
+
+	var request struct {
+		BatchSize    *int    `json:"batch_size"`
+		PollInterval *string `json:"poll_interval"`
+		Paused       *bool   `json:"paused"`
+	}
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	current := p.Settings()
+	cfg := config.ProcessorConfig{
+		BatchSize:    current.BatchSize,
+		PollInterval: current.PollInterval,
+		Paused:       current.Paused,
+	}
+	if request.BatchSize != nil {
+		cfg.BatchSize = *request.BatchSize
+	}
+	if request.PollInterval != nil {
+		interval, err := time.ParseDuration(*request.PollInterval)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid poll_interval"})
+		}
+		cfg.PollInterval = interval
+	}
+	if request.Paused != nil {
+		cfg.Paused = *request.Paused
+	}
+
+	if err := p.Apply(cfg, SourceAPI); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, p.Settings())
+}
+
+func (h *Handler) Pause(c echo.Context) error {
+	p, ok := h.registry.Get(c.Param("name"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "processor not found"})
+	}
+	p.Pause(SourceAPI)
+	return c.JSON(http.StatusOK, p.Settings())
+}
+
+func (h *Handler) Resume(c echo.Context) error {
+	p, ok := h.registry.Get(c.Param("name"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "processor not found"})
+	}
+	p.Resume(SourceAPI)
+	return c.JSON(http.StatusOK, p.Settings())
+}
@@ -0,0 +1,216 @@
+package tunables
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"sd_hw4/pkg/config"
+)
+
+// Источники последнего изменения параметров.
+const (
+	SourceConfig = "config"
+	SourceReload = "reload"
+	SourceAPI    = "api"
+)
+
+// Settings — действующие параметры фонового обработчика.
+type Settings struct {
+	Name         string
+	BatchSize    int
+	PollInterval time.Duration
+	Paused       bool
+	Source       string
+	UpdatedAt    time.Time
+}
+
+// MarshalJSON выводит интервал опроса в человекочитаемом виде ("5s").
+func (s Settings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name         string    `json:"name"`
+		BatchSize    int       `json:"batch_size"`
+		PollInterval string    `json:"poll_interval"`
+		Paused       bool      `json:"paused"`
+		Source       string    `json:"source"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}{s.Name, s.BatchSize, s.PollInterval.String(), s.Paused, s.Source, s.UpdatedAt})
+}
+
+// Check проверяет конфигурацию сервиса с новыми параметрами обработчиков (ключ — имя обработчика):
+// параметры обработчика связаны с остальными настройками, например outbox.lease не может быть
+// короче processors.outbox_relay.poll_interval.
+type Check func(processors map[string]config.ProcessorConfig) error
+
+// Processor хранит параметры одного фонового обработчика и оповещает цикл обработки об их изменении.
+type Processor struct {
+	registry *Registry
+	mu       sync.RWMutex
+	settings Settings
+	changed  chan struct{}
+}
+
+func newProcessor(registry *Registry, name string, cfg config.ProcessorConfig) *Processor {
+	return &Processor{
+		registry: registry,
+		settings: Settings{
+			Name:         name,
+			BatchSize:    cfg.BatchSize,
+			PollInterval: cfg.PollInterval,
+			Paused:       cfg.Paused,
+			Source:       SourceConfig,
+			UpdatedAt:    time.Now(),
+		},
+		changed: make(chan struct{}),
+	}
+}
+
+// Settings возвращает копию текущих параметров.
+func (p *Processor) Settings() Settings {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings
+}
+
+// Apply заменяет параметры обработчика целиком (используется при перечитывании конфигурации
+// и в административном API). Кроме самих параметров проверяется конфигурация сервиса с ними (см. Check).
+func (p *Processor) Apply(cfg config.ProcessorConfig, source string) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := p.registry.check(p.Settings().Name, cfg); err != nil {
+		return err
+	}
+	p.update(source, func(s *Settings) {
+		s.BatchSize = cfg.BatchSize
+		s.PollInterval = cfg.PollInterval
+		s.Paused = cfg.Paused
+	})
+	return nil
+}
+
+// Pause приостанавливает обработку: цикл продолжает работать, но не выбирает новые сообщения.
+func (p *Processor) Pause(source string) {
+	p.update(source, func(s *Settings) { s.Paused = true })
+}
+
+// Resume возобновляет обработку.
+func (p *Processor) Resume(source string) {
+	p.update(source, func(s *Settings) { s.Paused = false })
+}
+
+func (p *Processor) update(source string, fn func(s *Settings)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fn(&p.settings)
+	p.settings.Source = source
+	p.settings.UpdatedAt = time.Now()
+
+	// Закрытие канала будит всех, кто ждет изменения; для следующего изменения заводим новый.
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *Processor) watch() (Settings, <-chan struct{}) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.settings, p.changed
+}
+
+// Run вызывает tick с текущим размером пачки раз в PollInterval, пока не отменен ctx.
+// Сигнал из wake (например, уведомление о новой строке в таблице) вызывает tick сразу,
+// таймер при этом остается страховкой на случай потерянного уведомления; wake может быть nil.
+// Изменение параметров применяется сразу, без ожидания очередного тика;
+// на паузе tick не вызывается.
+func (p *Processor) Run(ctx context.Context, wake <-chan struct{}, tick func(ctx context.Context, batchSize int)) {
+	for {
+		settings, changed := p.watch()
+		timer := time.NewTimer(settings.PollInterval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-changed:
+			timer.Stop()
+		case <-wake:
+			timer.Stop()
+			if !settings.Paused {
+				tick(ctx, settings.BatchSize)
+			}
+		case <-timer.C:
+			if !settings.Paused {
+				tick(ctx, settings.BatchSize)
+			}
+		}
+	}
+}
+
+// Registry — набор настраиваемых обработчиков сервиса.
+type Registry struct {
+	mu         sync.RWMutex
+	processors map[string]*Processor
+	validate   Check
+}
+
+// NewRegistry создает набор обработчиков; validate может быть nil.
+func NewRegistry(validate Check) *Registry {
+	return &Registry{
+		processors: make(map[string]*Processor),
+		validate:   validate,
+	}
+}
+
+// Register добавляет обработчик с начальными параметрами из конфигурации.
+func (r *Registry) Register(name string, cfg config.ProcessorConfig) *Processor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := newProcessor(r, name, cfg)
+	r.processors[name] = p
+	return p
+}
+
+// Get возвращает обработчик по имени.
+func (r *Registry) Get(name string) (*Processor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.processors[name]
+	return p, ok
+}
+
+// Snapshot возвращает параметры всех обработчиков, отсортированные по имени.
+func (r *Registry) Snapshot() []Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]Settings, 0, len(r.processors))
+	for _, p := range r.processors {
+		result = append(result, p.Settings())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// check вызывает validate с действующими параметрами всех обработчиков, в которых параметры
+// обработчика name заменены на cfg.
+func (r *Registry) check(name string, cfg config.ProcessorConfig) error {
+	if r.validate == nil {
+		return nil
+	}
+
+	candidate := make(map[string]config.ProcessorConfig)
+	for _, settings := range r.Snapshot() {
+		candidate[settings.Name] = config.ProcessorConfig{
+			BatchSize:    settings.BatchSize,
+			PollInterval: settings.PollInterval,
+			Paused:       settings.Paused,
+		}
+	}
+	candidate[name] = cfg
+	return r.validate(candidate)
+}
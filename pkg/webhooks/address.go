@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenAddress — адрес получателя ведет во внутреннюю сеть (loopback, частные,
+// link-local и служебные диапазоны). Такие адреса запрещены, чтобы через вебхуки нельзя было
+// обращаться к сервисам, базам и метаданным облака изнутри сети сервиса.
+var ErrForbiddenAddress = errors.New("webhook address is not publicly routable")
+
+// ErrInvalidURL — адрес не является абсолютным http(s) URL.
+var ErrInvalidURL = errors.New("webhook url must be an absolute http(s) URL")
+
+// forbiddenPrefixes — диапазоны, которые не покрываются методами netip.Addr.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "эта сеть"
+	netip.MustParsePrefix("100.64.0.0/10"), // общий адрес провайдера (CGNAT), метаданные некоторых облаков
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64: ведет на произвольный IPv4, в том числе внутренний
+}
+
+// publicAddr сообщает, можно ли отправлять вебхук на адрес ip.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL проверяет адрес получателя при регистрации: URL должен быть абсолютным http(s),
+// а все адреса, в которые разрешается его хост, — публичными (если allowPrivate не задан,
+// см. DispatcherOptions.AllowPrivateAddresses). Проверка при регистрации
+// не заменяет проверку при соединении (см. dialControl): DNS может позже вернуть другой адрес.
+func ValidateURL(ctx context.Context, raw string, allowPrivate bool) (*url.URL, error) {
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, ErrInvalidURL
+	}
+	if allowPrivate {
+		return target, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return nil, fmt.Errorf("%w: host %q cannot be resolved", ErrForbiddenAddress, target.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return nil, fmt.Errorf("%w: host %q", ErrForbiddenAddress, target.Hostname())
+		}
+	}
+	return target, nil
+}
+
+// dialControl проверяет адрес непосредственно перед соединением (net.Dialer.Control), поэтому
+// хост, который после регистрации стал разрешаться во внутренний адрес (DNS rebinding), отклоняется.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenAddress, err)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
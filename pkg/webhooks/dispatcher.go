@@ -0,0 +1,170 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sd_hw4/pkg/backoff"
+	"sd_hw4/pkg/tunables"
+)
+
+// maxDrainBody — сколько байт ответа получателя дочитывается, чтобы соединение можно было
+// использовать повторно. Тело ответа не сохраняется: last_error видит владелец адреса.
+const maxDrainBody = 4 << 10
+
+// DispatcherOptions — параметры диспетчера.
+type DispatcherOptions struct {
+	// Owner — идентификатор экземпляра сервиса, от имени которого захватываются доставки.
+	Owner string
+	// Lease — срок аренды захваченной пачки; должен превышать RequestTimeout.
+	Lease time.Duration
+	// RequestTimeout — сколько ждать ответа получателя.
+	RequestTimeout time.Duration
+	// Retry определяет задержки между попытками и их предельное число.
+	Retry backoff.Policy
+	// Wake будит диспетчер вне очереди (см. NotifyChannel); может быть nil.
+	Wake <-chan struct{}
+	// AllowPrivateAddresses разрешает доставку на адреса внутренней сети (см. ErrForbiddenAddress).
+	// Только для тестов и локальной отладки с получателем на localhost.
+	AllowPrivateAddresses bool
+}
+
+// Dispatcher отправляет доставки получателям. Ответ 2xx завершает доставку, любой другой
+// ответ или ошибка соединения планирует повтор, после Retry.MaxAttempts попыток доставка
+// получает статус dead. Несколько диспетчеров (реплики сервиса) могут работать одновременно.
+type Dispatcher struct {
+	store    *Store
+	client   *http.Client
+	tunables *tunables.Processor
+	owner    string
+	lease    time.Duration
+	retry    backoff.Policy
+	wake     <-chan struct{}
+}
+
+func NewDispatcher(store *Store, tunables *tunables.Processor, opts DispatcherOptions) *Dispatcher {
+	dialer := &net.Dialer{Timeout: opts.RequestTimeout}
+	if !opts.AllowPrivateAddresses {
+		dialer.Control = dialControl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Без прокси: адрес проверяется при соединении, а соединение с прокси проверило бы адрес прокси
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.RequestTimeout,
+			// Перенаправление не считается доставкой: получатель должен ответить 2xx сам
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		tunables: tunables,
+		owner:    opts.Owner,
+		lease:    opts.Lease,
+		retry:    opts.Retry,
+		wake:     opts.Wake,
+	}
+}
+
+// Run запускает цикл доставки; завершается при отмене ctx.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.tunables.Run(ctx, d.wake, d.ProcessBatch)
+	log.Println("Webhook dispatcher stopped")
+}
+
+// ProcessBatch отправляет доставки, время которых наступило, пачками по batchSize.
+// Доставки пачки отправляются параллельно, поэтому медленный получатель не задерживает остальных.
+func (d *Dispatcher) ProcessBatch(ctx context.Context, batchSize int) {
+	for ctx.Err() == nil {
+		deliveries, err := d.store.Claim(ctx, d.owner, d.lease, batchSize)
+		if err != nil {
+			log.Printf("Failed to claim webhook deliveries: %v", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver выполняет одну попытку и записывает ее результат.
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) {
+	statusCode, err := d.send(ctx, delivery)
+	// Результат записывается и при остановке: иначе доставка ждала бы истечения аренды
+	ctx = context.WithoutCancel(ctx)
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+
+	if err == nil {
+		if markErr := d.store.MarkDelivered(ctx, delivery.ID, d.owner, statusCode); markErr != nil {
+			log.Printf("Failed to mark webhook delivery %d as delivered: %v", delivery.ID, markErr)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	if d.retry.Exhausted(attempts) {
+		if markErr := d.store.MarkDead(ctx, delivery.ID, d.owner, code, err.Error()); markErr != nil {
+			log.Printf("Failed to mark webhook delivery %d as dead: %v", delivery.ID, markErr)
+			return
+		}
+		log.Printf("Webhook delivery %d to %s is dead after %d attempts: %v", delivery.ID, delivery.URL, attempts, err)
+		return
+	}
+
+	retryAt := time.Now().Add(d.retry.Delay(attempts))
+	if markErr := d.store.MarkFailed(ctx, delivery.ID, d.owner, code, err.Error(), retryAt); markErr != nil {
+		log.Printf("Failed to mark webhook delivery %d as failed: %v", delivery.ID, markErr)
+	}
+}
+
+// send отправляет подписанное событие и возвращает код ответа (0, если ответа не было)
+// и ошибку, если доставка не удалась.
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sd_hw4-webhooks")
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,230 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "local-test-secret-123"
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"event-1"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		err    error
+	}{
+		{name: "valid", secret: testSecret, header: Sign(testSecret, now, body), body: body},
+		{name: "tampered body", secret: testSecret, header: Sign(testSecret, now, body), body: []byte(`{"id":"event-2"}`), err: ErrInvalidSignature},
+		{name: "other secret", secret: "another-secret-4567", header: Sign(testSecret, now, body), body: body, err: ErrInvalidSignature},
+		{name: "expired", secret: testSecret, header: Sign(testSecret, now.Add(-2*DefaultTolerance), body), body: body, err: ErrSignatureExpired},
+		{name: "from the future", secret: testSecret, header: Sign(testSecret, now.Add(2*DefaultTolerance), body), body: body, err: ErrSignatureExpired},
+		{name: "empty header", secret: testSecret, header: "", body: body, err: ErrInvalidSignature},
+		{name: "no signature", secret: testSecret, header: "t=" + strconv.FormatInt(now.Unix(), 10), body: body, err: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, DefaultTolerance)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSendToReceiver(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []Event
+	)
+	receiver := httptest.NewServer(Receiver(testSecret, DefaultTolerance, func(event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, event)
+		return nil
+	}))
+	defer receiver.Close()
+
+	delivery := testDelivery(t, receiver.URL)
+	status, err := testDispatcher(true).send(context.Background(), delivery)
+	if err != nil {
+		t.Fatalf("send error = %v", err)
+	}
+	if status != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", status, http.StatusNoContent)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0].ID != delivery.EventID || received[0].Type != delivery.EventType {
+		t.Fatalf("received = %+v, want event %s", received, delivery.EventID)
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	// Получатель с другим секретом отвечает 401: доставка не завершена
+	receiver := httptest.NewServer(Receiver("another-secret-4567", DefaultTolerance, func(Event) error { return nil }))
+	defer receiver.Close()
+
+	status, err := testDispatcher(true).send(context.Background(), testDelivery(t, receiver.URL))
+	if err == nil {
+		t.Fatal("send succeeded, want error")
+	}
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", status, http.StatusUnauthorized)
+	}
+	// Тело ответа получателя не попадает в ошибку, которую видит владелец адреса
+	if strings.Contains(err.Error(), ErrInvalidSignature.Error()) {
+		t.Fatalf("error %q contains the receiver response body", err)
+	}
+}
+
+func TestSendReceiverError(t *testing.T) {
+	receiver := httptest.NewServer(Receiver(testSecret, DefaultTolerance, func(Event) error {
+		return errors.New("internal details")
+	}))
+	defer receiver.Close()
+
+	status, err := testDispatcher(true).send(context.Background(), testDelivery(t, receiver.URL))
+	if err == nil || status != http.StatusInternalServerError {
+		t.Fatalf("send = %d, %v, want %d and error", status, err, http.StatusInternalServerError)
+	}
+	if strings.Contains(err.Error(), "internal details") {
+		t.Fatalf("error %q contains the receiver response body", err)
+	}
+}
+
+func TestSendRedirect(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(Receiver(testSecret, DefaultTolerance, func(Event) error {
+		followed = true
+		return nil
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	status, err := testDispatcher(true).send(context.Background(), testDelivery(t, redirect.URL))
+	if err == nil {
+		t.Fatal("redirect counted as delivered")
+	}
+	if status != http.StatusTemporaryRedirect {
+		t.Fatalf("status = %d, want %d", status, http.StatusTemporaryRedirect)
+	}
+	if followed {
+		t.Fatal("redirect was followed")
+	}
+}
+
+func TestSendPrivateAddress(t *testing.T) {
+	var called bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	status, err := testDispatcher(false).send(context.Background(), testDelivery(t, receiver.URL))
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("send error = %v, want %v", err, ErrForbiddenAddress)
+	}
+	if status != 0 || called {
+		t.Fatalf("request to a loopback address was sent (status %d)", status)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"0.1.2.3", false},
+		{"100.100.100.200", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		err          error
+	}{
+		{url: "https://8.8.8.8/hook"},
+		{url: "http://127.0.0.1:8080/hook", allowPrivate: true},
+		{url: "http://127.0.0.1:8080/hook", err: ErrForbiddenAddress},
+		{url: "http://localhost/hook", err: ErrForbiddenAddress},
+		{url: "http://[::1]/hook", err: ErrForbiddenAddress},
+		{url: "http://169.254.169.254/latest/meta-data", err: ErrForbiddenAddress},
+		{url: "ftp://8.8.8.8/hook", err: ErrInvalidURL},
+		{url: "/hook", err: ErrInvalidURL},
+		{url: "http:///hook", err: ErrInvalidURL},
+	}
+	for _, tt := range tests {
+		_, err := ValidateURL(context.Background(), tt.url, tt.allowPrivate)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ValidateURL(%q, %v) error = %v, want %v", tt.url, tt.allowPrivate, err, tt.err)
+		}
+	}
+}
+
+// testDispatcher возвращает диспетчер без хранилища: send его не использует.
+func testDispatcher(allowPrivate bool) *Dispatcher {
+	return NewDispatcher(nil, nil, DispatcherOptions{
+		RequestTimeout:        5 * time.Second,
+		AllowPrivateAddresses: allowPrivate,
+	})
+}
+
+func testDelivery(t *testing.T, url string) Delivery {
+	t.Helper()
+	payload, err := json.Marshal(Event{
+		ID:        "event-1",
+		Type:      EventPing,
+		CreatedAt: time.Now().UTC(),
+		Data:      json.RawMessage(`{}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Delivery{
+		ID:        1,
+		EventID:   "event-1",
+		EventType: EventPing,
+		Payload:   payload,
+		URL:       url,
+		Secret:    testSecret,
+	}
+}
@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"context"
+	"log"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Publisher получает от relay сообщения outbox с exchange Exchange (см. outbox.Router)
+// и ставит доставки адресам владельца. Сообщение отправлено, когда доставки записаны;
+// ошибка записи оставляет сообщение в outbox для повтора.
+type Publisher struct {
+	store *Store
+}
+
+func NewPublisher(store *Store) *Publisher {
+	return &Publisher{store: store}
+}
+
+// Publish ставит доставки события msg.Type владельцу routingKey. Тело сообщения становится
+// полем data события, MessageId — его ID.
+func (p *Publisher) Publish(ctx context.Context, _, routingKey string, msg amqp.Publishing) error {
+	event := Event{
+		ID:        msg.MessageId,
+		Type:      msg.Type,
+		CreatedAt: msg.Timestamp,
+		Data:      msg.Body,
+	}
+
+	enqueued, err := p.store.Enqueue(ctx, routingKey, event)
+	if err != nil {
+		return err
+	}
+	if enqueued > 0 {
+		log.Printf("Enqueued %d webhook deliveries of %s %s", enqueued, event.Type, event.ID)
+	}
+	return nil
+}
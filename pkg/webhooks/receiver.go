@@ -0,0 +1,43 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxEventBody — предельный размер тела события, которое принимает Receiver.
+const maxEventBody = 1 << 20
+
+// Receiver — http.Handler получателя вебхуков: проверяет подпись секретом, разбирает событие
+// и передает его handle. Ответ 2xx подтверждает доставку; ошибка handle возвращает 500,
+// и отправитель повторит доставку. Повторы приходят с тем же Event.ID.
+func Receiver(secret string, tolerance time.Duration, handle func(Event) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxEventBody))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if err := Verify(secret, r.Header.Get(SignatureHeader), body, tolerance); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+		if err := handle(event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,79 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса к получателю.
+const (
+	// SignatureHeader — "t=<unix time>,v1=<hex HMAC-SHA256 секрета от "<t>.<тело>">".
+	SignatureHeader = "Webhook-Signature"
+	EventIDHeader   = "Webhook-Id"
+	EventTypeHeader = "Webhook-Event"
+	// DeliveryHeader — id доставки; у повторов одной доставки он тот же.
+	DeliveryHeader = "Webhook-Delivery"
+)
+
+// DefaultTolerance — допустимое расхождение времени подписи и часов получателя для Verify.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrSignatureExpired = errors.New("webhook signature timestamp is outside the tolerance")
+)
+
+// Sign возвращает значение заголовка SignatureHeader для тела body, подписанного в момент at.
+// Время входит в подпись, поэтому перехваченный запрос нельзя повторить позже tolerance.
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + signature(secret, timestamp, body)
+}
+
+// Verify проверяет заголовок SignatureHeader запроса с телом body. Получатель вызывает его
+// с секретом, выданным при регистрации адреса.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := signature(secret, timestamp, body)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
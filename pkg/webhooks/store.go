@@ -0,0 +1,299 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Store — адреса и доставки вебхуков в Postgres.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const endpointColumns = `id, owner_id, url, secret, events, created_at`
+
+// CreateEndpoint сохраняет адрес; ID и CreatedAt заполняются, если не заданы.
+func (s *Store) CreateEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	if endpoint.ID == uuid.Nil {
+		endpoint.ID = uuid.New()
+	}
+	if endpoint.CreatedAt.IsZero() {
+		endpoint.CreatedAt = time.Now()
+	}
+	if endpoint.Events == nil {
+		endpoint.Events = []string{}
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO webhook_endpoints (`+endpointColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		endpoint.ID, endpoint.OwnerID, endpoint.URL, endpoint.Secret, pq.Array(endpoint.Events), endpoint.CreatedAt)
+	return err
+}
+
+// ListEndpoints возвращает адреса владельца в порядке регистрации.
+func (s *Store) ListEndpoints(ctx context.Context, ownerID string) ([]Endpoint, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+endpointColumns+`
+		FROM webhook_endpoints WHERE owner_id = $1 ORDER BY created_at, id`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []Endpoint
+	for rows.Next() {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+// GetEndpoint возвращает адрес владельца или ErrNotFound, если его нет или он принадлежит другому владельцу.
+func (s *Store) GetEndpoint(ctx context.Context, ownerID string, id uuid.UUID) (*Endpoint, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+endpointColumns+`
+		FROM webhook_endpoints WHERE owner_id = $1 AND id = $2`, ownerID, id)
+	endpoint, err := scanEndpoint(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// DeleteEndpoint удаляет адрес владельца вместе с журналом его доставок.
+func (s *Store) DeleteEndpoint(ctx context.Context, ownerID string, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE owner_id = $1 AND id = $2`, ownerID, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Enqueue ставит доставку события каждому адресу владельца, подписанному на его тип,
+// и возвращает число новых доставок. Повтор того же события (тот же ID) доставок не добавляет.
+func (s *Store) Enqueue(ctx context.Context, ownerID string, event Event) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		SELECT id, $2, $3, $4 FROM webhook_endpoints
+		WHERE owner_id = $1 AND (cardinality(events) = 0 OR $3 = ANY(events))
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING`,
+		ownerID, event.ID, event.Type, payload)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// EnqueueTo ставит доставку события одному адресу независимо от его подписки (например, EventPing).
+func (s *Store) EnqueueTo(ctx context.Context, endpointID uuid.UUID, event Event) (*Delivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+deliveryColumns, endpointID, event.ID, event.Type, payload)
+	delivery, err := scanDelivery(row)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+const deliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_attempt_at, last_status_code, last_error, delivered_at, created_at`
+
+// Claim захватывает до limit доставок, время попытки которых наступило, в аренду owner
+// на время lease и возвращает их вместе с адресом и секретом. Строки выбираются
+// с FOR UPDATE SKIP LOCKED, поэтому несколько диспетчеров не получают одни и те же доставки.
+func (s *Store) Claim(ctx context.Context, owner string, lease time.Duration, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `UPDATE webhook_deliveries d
+		SET claimed_by = $1, lease_until = now() + make_interval(secs => $2)
+		FROM webhook_endpoints e
+		WHERE e.id = d.endpoint_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ('pending', 'failed')
+				AND next_attempt_at <= now()
+				AND (lease_until IS NULL OR lease_until < now())
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+			d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at, e.url, e.secret`,
+		owner, lease.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt,
+			&d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING не сохраняет порядок подзапроса
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	return deliveries, nil
+}
+
+// MarkDelivered, MarkFailed и MarkDead записывают результат попытки и снимают аренду;
+// если доставка уже не принадлежит owner, возвращается ErrLeaseLost.
+func (s *Store) MarkDelivered(ctx context.Context, id int64, owner string, statusCode int) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_attempt_at = now(), last_status_code = $2,
+			last_error = NULL, delivered_at = now(), claimed_by = NULL, lease_until = NULL
+		WHERE id = $3 AND claimed_by = $4`,
+		StatusDelivered, statusCode, id, owner)
+	return checkLease(result, err)
+}
+
+// MarkFailed фиксирует неудачную попытку и планирует следующую на retryAt.
+// statusCode — nil, если получатель не ответил.
+func (s *Store) MarkFailed(ctx context.Context, id int64, owner string, statusCode *int, reason string, retryAt time.Time) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_attempt_at = now(), last_status_code = $2,
+			last_error = $3, next_attempt_at = $4, claimed_by = NULL, lease_until = NULL
+		WHERE id = $5 AND claimed_by = $6`,
+		StatusFailed, statusCode, reason, retryAt, id, owner)
+	return checkLease(result, err)
+}
+
+// MarkDead фиксирует последнюю неудачную попытку и переводит доставку в конечный статус dead.
+func (s *Store) MarkDead(ctx context.Context, id int64, owner string, statusCode *int, reason string) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_attempt_at = now(), last_status_code = $2,
+			last_error = $3, claimed_by = NULL, lease_until = NULL
+		WHERE id = $4 AND claimed_by = $5`,
+		StatusDead, statusCode, reason, id, owner)
+	return checkLease(result, err)
+}
+
+// ListDeliveries возвращает до limit доставок адреса, начиная с самых новых; пустой status
+// не ограничивает выборку.
+func (s *Store) ListDeliveries(ctx context.Context, endpointID uuid.UUID, status Status, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1 AND ($2::varchar = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3`, endpointID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Redeliver снова ставит доставку адреса endpointID в очередь с полным запасом попыток,
+// в каком бы конечном статусе она ни была. Тело события не меняется, поэтому получатель
+// может отбросить дубль по ID события. Выполняемую сейчас доставку повторить нельзя (ErrInvalidState).
+func (s *Store) Redeliver(ctx context.Context, endpointID uuid.UUID, id int64) (*Delivery, error) {
+	row := s.db.QueryRowContext(ctx, `UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = now(), claimed_by = NULL, lease_until = NULL
+		WHERE id = $2 AND endpoint_id = $3 AND (lease_until IS NULL OR lease_until < now())
+		RETURNING `+deliveryColumns, StatusPending, id, endpointID)
+	delivery, err := scanDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1 AND endpoint_id = $2)`,
+			id, endpointID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrInvalidState
+		}
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Триггер будит диспетчеры только при вставке
+	if _, err := s.db.ExecContext(ctx, `SELECT pg_notify($1, '')`, NotifyChannel); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEndpoint(row scanner) (Endpoint, error) {
+	var endpoint Endpoint
+	err := row.Scan(&endpoint.ID, &endpoint.OwnerID, &endpoint.URL, &endpoint.Secret,
+		pq.Array(&endpoint.Events), &endpoint.CreatedAt)
+	return endpoint, err
+}
+
+func scanDelivery(row scanner) (Delivery, error) {
+	var d Delivery
+	err := row.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+	return d, err
+}
+
+func checkLease(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
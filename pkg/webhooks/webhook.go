@@ -0,0 +1,88 @@
+// Package webhooks доставляет события внешним получателям (вебхуки). Владелец (пользователь,
+// партнер) регистрирует адрес с секретом; событие, записанное в outbox с exchange Exchange,
+// relay передает Publisher, который ставит доставку каждому подписанному адресу владельца.
+// Dispatcher отправляет доставки POST-запросами с подписью (см. Sign) и повторяет неудачные
+// с экспоненциальной задержкой.
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Exchange — exchange сообщений outbox, которые доставляются вебхуками, а не публикуются
+	// в брокер. Routing key сообщения — владелец адресов, Type — тип события.
+	Exchange = "webhooks"
+	// NotifyChannel — канал Postgres NOTIFY, в который триггер сообщает о новых доставках.
+	NotifyChannel = "webhook_deliveries"
+	// EventPing — проверочное событие, которое отправляется на один адрес по запросу владельца.
+	EventPing = "webhook.ping"
+)
+
+var (
+	ErrNotFound = errors.New("webhook not found")
+	// ErrInvalidState — доставка сейчас выполняется и не может быть поставлена в очередь заново.
+	ErrInvalidState = errors.New("webhook delivery is in progress")
+	// ErrLeaseLost возвращается, если доставка больше не принадлежит экземпляру:
+	// аренда истекла и ее захватил другой диспетчер.
+	ErrLeaseLost = errors.New("webhook delivery lease lost")
+)
+
+// Event — тело запроса к получателю.
+type Event struct {
+	// ID — идентификатор события (message_id сообщения outbox), одинаковый во всех доставках
+	// и повторах; по нему получатель отбрасывает дубли.
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Endpoint — адрес, на который доставляются события владельца.
+type Endpoint struct {
+	ID      uuid.UUID
+	OwnerID string
+	URL     string
+	// Secret — ключ подписи запросов; в ответах API возвращается только при создании.
+	Secret string
+	// Events — типы событий, на которые подписан адрес; пустой список — все события.
+	Events    []string
+	CreatedAt time.Time
+}
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	// StatusFailed — попытка не удалась, следующая запланирована на NextAttemptAt.
+	StatusFailed    Status = "failed"
+	StatusDelivered Status = "delivered"
+	// StatusDead — попытки исчерпаны; доставку можно повторить вручную (Store.Redeliver).
+	StatusDead Status = "dead"
+)
+
+// Delivery — событие для одного адреса и результат последней попытки его доставить.
+type Delivery struct {
+	ID            int64
+	EndpointID    uuid.UUID
+	EventID       string
+	EventType     string
+	Payload       []byte
+	Status        Status
+	Attempts      int
+	NextAttemptAt time.Time
+	LastAttemptAt *time.Time
+	// LastStatusCode — код ответа получателя; nil, если ответа не было (таймаут, ошибка соединения).
+	LastStatusCode *int
+	LastError      *string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+
+	// URL и Secret адреса заполняются при захвате доставки диспетчером.
+	URL    string
+	Secret string
+}